@@ -0,0 +1,165 @@
+package levenshtein
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/gnames/levenshtein/presenter"
+)
+
+// CacheStats shows how efficient a cache is.
+type CacheStats struct {
+	// Hits is the number of comparisons found in the cache.
+	Hits int
+
+	// Misses is the number of comparisons that had to be calculated.
+	Misses int
+
+	// Evictions is the number of results removed from the cache to free
+	// space for new ones.
+	Evictions int
+
+	// Len is the number of results in the cache.
+	Len int
+}
+
+// HitRate returns the share of comparisons found in the cache.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Cache keeps results of recent comparisons, so repeated pairs of
+// strings are not calculated again. When the cache is full, the least
+// recently used result is removed. Results are keyed on both strings and
+// on settings that change results, so one cache can be shared by several
+// Levenshtein objects with different options. Cache is safe for
+// concurrent use. All workers share one lock, so for short strings that
+// are rarely repeated the cache can be slower than calculating results,
+// BenchmarkCompareMultCache shows the difference.
+type Cache struct {
+	size int
+
+	mu    sync.Mutex
+	items map[cacheKey]*list.Element
+	// lru keeps cacheItem values, the most recently used first.
+	lru   *list.List
+	stats CacheStats
+}
+
+type cacheKey struct {
+	str1, str2 string
+	// opts describes settings that change results.
+	opts string
+}
+
+type cacheItem struct {
+	key cacheKey
+	out presenter.Output
+}
+
+// NewCache creates a cache for up to size results.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:  max(size, 1),
+		items: make(map[cacheKey]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// Size returns the maximum number of results in the cache.
+func (c *Cache) Size() int {
+	if c == nil {
+		return 0
+	}
+	return c.size
+}
+
+// Stats returns hits, misses and other statistics of the cache. A nil
+// cache has empty statistics.
+func (c *Cache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := c.stats
+	res.Len = c.lru.Len()
+	return res
+}
+
+// Reset removes all results and statistics from the cache. It does
+// nothing for a nil cache.
+func (c *Cache) Reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+	c.lru.Init()
+	c.stats = CacheStats{}
+}
+
+func (c *Cache) get(k cacheKey) (presenter.Output, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[k]
+	if !ok {
+		c.stats.Misses++
+		return presenter.Output{}, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(e)
+	return ownCost(e.Value.(*cacheItem).out), true
+}
+
+func (c *Cache) add(k cacheKey, out presenter.Output) {
+	out = ownCost(out)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// another worker might have added the same pair.
+	if e, ok := c.items[k]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+	if c.lru.Len() >= c.size {
+		e := c.lru.Back()
+		delete(c.items, e.Value.(*cacheItem).key)
+		// the evicted element is reused for the new result.
+		item := e.Value.(*cacheItem)
+		item.key, item.out = k, out
+		c.items[k] = e
+		c.lru.MoveToFront(e)
+		c.stats.Evictions++
+		return
+	}
+	c.items[k] = c.lru.PushFront(&cacheItem{key: k, out: out})
+}
+
+// ownCost gives the output its own copy of Cost, so results that come
+// from the cache and the cached results do not change each other.
+func ownCost(out presenter.Output) presenter.Output {
+	if out.Cost != nil {
+		c := *out.Cost
+		out.Cost = &c
+	}
+	return out
+}
+
+// OptCache sets a cache for results of Compare and CompareMult. Nil
+// disables the cache, which is the default.
+func OptCache(c *Cache) Option {
+	return func(l *levenshtein) {
+		l.cache = c
+	}
+}
+
+// cacheKeyOpts describes settings that change results of comparisons.
+func (l levenshtein) cacheKeyOpts() string {
+	return fmt.Sprintf("%t|%d|%t|%g|%d",
+		l.withDiff, l.maxEditDist, l.pattern, l.caseCost, l.invalidUTF8)
+}
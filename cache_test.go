@@ -0,0 +1,158 @@
+package levenshtein_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/gnames/levenshtein"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	c := levenshtein.NewCache(2)
+	fd := levenshtein.NewLevenshtein(levenshtein.OptCache(c))
+	assert.Equal(t, 1, fd.Compare("Puma", "Poma").EditDist)
+	assert.Equal(t, 1, fd.Compare("Puma", "Poma").EditDist)
+	assert.Equal(t, levenshtein.CacheStats{Hits: 1, Misses: 1, Len: 1},
+		c.Stats())
+
+	fd.Compare("Pomatomus", "Pomatomas")
+	// Puma is used recently, so Pomatomus is evicted.
+	fd.Compare("Puma", "Poma")
+	fd.Compare("Boston", "Chicago")
+	fd.Compare("Puma", "Poma")
+	fd.Compare("Pomatomus", "Pomatomas")
+	st := c.Stats()
+	assert.Equal(t, levenshtein.CacheStats{
+		Hits: 3, Misses: 4, Evictions: 2, Len: 2,
+	}, st)
+	assert.Equal(t, 3.0/7.0, st.HitRate())
+
+	c.Reset()
+	assert.Equal(t, levenshtein.CacheStats{}, c.Stats())
+	assert.Equal(t, 0.0, c.Stats().HitRate())
+
+	// a nil cache is safe to use.
+	var nc *levenshtein.Cache
+	nc.Reset()
+	assert.Equal(t, levenshtein.CacheStats{}, nc.Stats())
+	assert.Equal(t, 0, nc.Size())
+}
+
+// TestCacheCost checks that a cost changed by a caller does not change
+// the cached result.
+func TestCacheCost(t *testing.T) {
+	c := levenshtein.NewCache(10)
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptCache(c),
+		levenshtein.OptCaseCost(0.25),
+	)
+	out := fd.Compare("Boston", "boston")
+	*out.Cost = 10
+	out = fd.Compare("Boston", "boston")
+	*out.Cost = 20
+	out = fd.Compare("Boston", "boston")
+	assert.Equal(t, 2, c.Stats().Hits)
+	if assert.NotNil(t, out.Cost) {
+		assert.Equal(t, 0.25, *out.Cost)
+	}
+}
+
+func TestCacheOpts(t *testing.T) {
+	c := levenshtein.NewCache(100)
+	fd := levenshtein.NewLevenshtein(levenshtein.OptCache(c))
+	fdDiff := levenshtein.NewLevenshtein(
+		levenshtein.OptCache(c),
+		levenshtein.OptWithDiff(true),
+	)
+	fdMax := levenshtein.NewLevenshtein(
+		levenshtein.OptCache(c),
+		levenshtein.OptMaxEditDist(1),
+	)
+
+	// the same pair with different options is kept separately.
+	assert.Equal(t, "", fd.Compare("Pomatomus", "Pomatomas").Tags1)
+	assert.Equal(t, "Pomatom<subst>u</subst>s",
+		fdDiff.Compare("Pomatomus", "Pomatomas").Tags1)
+	assert.False(t, fd.Compare("Puma", "Pomas").Aborted)
+	assert.True(t, fdMax.Compare("Puma", "Pomas").Aborted)
+	assert.Equal(t, 0, c.Stats().Hits)
+	assert.Equal(t, 4, c.Stats().Len)
+
+	cfg := fdMax.Config()
+	assert.Equal(t, c, cfg.Cache)
+	assert.Equal(t, 100, cfg.CacheSize)
+
+	cfg, err := levenshtein.NewConfigYAML([]byte("cacheSize: 10\n"))
+	assert.Nil(t, err)
+	fd = levenshtein.NewLevenshtein(cfg.Opts()...)
+	assert.Equal(t, 10, fd.Config().Cache.Size())
+	// the loaded config creates its cache once, so it is shared.
+	fd2 := levenshtein.NewLevenshtein(cfg.Opts()...)
+	assert.Same(t, fd.Config().Cache, fd2.Config().Cache)
+	fd.Compare("Puma", "Poma")
+	fd2.Compare("Puma", "Poma")
+	assert.Equal(t, 1, cfg.Cache.Stats().Hits)
+}
+
+func TestCacheMult(t *testing.T) {
+	str := fuzzyPairs(t, 1)[:1000]
+	str = append(str, str...)
+	exp := levenshtein.NewLevenshtein(levenshtein.OptWithDiff(true)).
+		CompareMult(str)
+
+	c := levenshtein.NewCache(len(str))
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptCache(c),
+		levenshtein.OptWithDiff(true),
+		levenshtein.OptJobs(4),
+	)
+	assert.Equal(t, exp, fd.CompareMult(str))
+	st := c.Stats()
+	assert.Equal(t, len(str), st.Hits+st.Misses)
+	assert.Equal(t, 1000, st.Len)
+
+	// the second run takes everything from the cache.
+	assert.Equal(t, exp, fd.CompareMult(str))
+	assert.Equal(t, st.Hits+len(str), c.Stats().Hits)
+
+	// small cache still gives correct results.
+	fd = levenshtein.NewLevenshtein(
+		levenshtein.OptCache(levenshtein.NewCache(10)),
+		levenshtein.OptWithDiff(true),
+	)
+	assert.Equal(t, exp, fd.CompareMult(str))
+}
+
+// BenchmarkCompareMultCache shows the cost of the shared cache lock with
+// many workers, when all pairs are found in the cache, and when none are.
+func BenchmarkCompareMultCache(b *testing.B) {
+	str := fuzzyPairs(b, 1)
+	jobs := levenshtein.OptJobs(runtime.GOMAXPROCS(0))
+	b.Run("NoCache", func(b *testing.B) {
+		fd := levenshtein.NewLevenshtein(jobs)
+		for i := 0; i < b.N; i++ {
+			_ = fd.CompareMult(str)
+		}
+	})
+	b.Run("CacheHits", func(b *testing.B) {
+		fd := levenshtein.NewLevenshtein(
+			jobs, levenshtein.OptCache(levenshtein.NewCache(len(str))),
+		)
+		_ = fd.CompareMult(str)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = fd.CompareMult(str)
+		}
+	})
+	b.Run("CacheMisses", func(b *testing.B) {
+		c := levenshtein.NewCache(len(str))
+		fd := levenshtein.NewLevenshtein(jobs, levenshtein.OptCache(c))
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			c.Reset()
+			b.StartTimer()
+			_ = fd.CompareMult(str)
+		}
+	})
+}
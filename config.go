@@ -0,0 +1,145 @@
+package levenshtein
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config keeps all settings of Levenshtein. Zero values of its fields
+// are the same as the defaults of corresponding options. Config can be
+// loaded from JSON or YAML, except for the progress function.
+type Config struct {
+	// WithDiff corresponds to OptWithDiff.
+	WithDiff bool `json:"withDiff" yaml:"withDiff"`
+
+	// MaxEditDist corresponds to OptMaxEditDist.
+	MaxEditDist int `json:"maxEditDistance" yaml:"maxEditDistance"`
+
+	// Pattern corresponds to OptPattern.
+	Pattern bool `json:"pattern" yaml:"pattern"`
+
+	// CaseCost corresponds to OptCaseCost.
+	CaseCost float64 `json:"caseCost" yaml:"caseCost"`
+
+	// Jobs corresponds to OptJobs.
+	Jobs int `json:"jobs" yaml:"jobs"`
+
+	// ChunkSize corresponds to OptChunkSize.
+	ChunkSize int `json:"chunkSize" yaml:"chunkSize"`
+
+	// ProgressEvery and ProgressFn correspond to OptProgress. The function
+	// cannot be loaded from a file, so it has to be set in code.
+	ProgressEvery int            `json:"progressEvery" yaml:"progressEvery"`
+	ProgressFn    func(Progress) `json:"-" yaml:"-"`
+
+	// InvalidUTF8 corresponds to OptInvalidUTF8, it is saved by the name
+	// of the mode: "replace", "error" or "bytes".
+	InvalidUTF8 UTF8Mode `json:"invalidUTF8" yaml:"invalidUTF8"`
+
+	// CacheSize is the size of the cache for OptCache. NewConfigJSON,
+	// NewConfigYAML and LoadConfig create the cache once, and keep it in
+	// Cache. If a Config is made in code with CacheSize, but without Cache,
+	// every call of Opts creates a new cache, so Levenshtein objects made
+	// from such options do not share results.
+	CacheSize int `json:"cacheSize" yaml:"cacheSize"`
+
+	// Cache corresponds to OptCache. It cannot be loaded from a file.
+	Cache *Cache `json:"-" yaml:"-"`
+}
+
+// Opts converts the Config to options for NewLevenshtein.
+func (c Config) Opts() []Option {
+	return []Option{
+		OptWithDiff(c.WithDiff),
+		OptMaxEditDist(c.MaxEditDist),
+		OptPattern(c.Pattern),
+		OptCaseCost(c.CaseCost),
+		OptJobs(c.Jobs),
+		OptChunkSize(c.ChunkSize),
+		OptProgress(c.ProgressEvery, c.ProgressFn),
+		OptInvalidUTF8(c.InvalidUTF8),
+		OptCache(c.cache()),
+	}
+}
+
+// cache returns the cache of the config, or creates a new one if only its
+// size is known.
+func (c Config) cache() *Cache {
+	if c.Cache == nil && c.CacheSize > 0 {
+		return NewCache(c.CacheSize)
+	}
+	return c.Cache
+}
+
+// withCache validates a loaded config, and creates its cache once, so all
+// options made from the config share it.
+func (c Config) withCache() (Config, error) {
+	if err := c.Validate(); err != nil {
+		return c, err
+	}
+	c.Cache = c.cache()
+	return c, nil
+}
+
+// Validate checks settings that cannot be normalized silently. Options
+// made from a Config with such settings ignore them, so Validate lets a
+// caller report the problem instead.
+func (c Config) Validate() error {
+	return checkCaseCost(c.CaseCost)
+}
+
+// checkCaseCost returns an error if the cost of case changes is negative,
+// infinite or NaN.
+func checkCaseCost(f float64) error {
+	if f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("case cost must be a non-negative number, got %v", f)
+	}
+	return nil
+}
+
+// NewConfigJSON creates Config from JSON data. Unknown fields are
+// treated as errors, so misspelled settings are not ignored silently.
+func NewConfigJSON(data []byte) (Config, error) {
+	var res Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&res); err != nil {
+		return res, fmt.Errorf("cannot decode JSON config: %w", err)
+	}
+	return res.withCache()
+}
+
+// NewConfigYAML creates Config from YAML data. Unknown fields are
+// treated as errors, so misspelled settings are not ignored silently.
+func NewConfigYAML(data []byte) (Config, error) {
+	var res Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	// empty document is a valid empty config.
+	if err := dec.Decode(&res); err != nil && !errors.Is(err, io.EOF) {
+		return res, fmt.Errorf("cannot decode YAML config: %w", err)
+	}
+	return res.withCache()
+}
+
+// LoadConfig reads Config from a file. Files with ".json" extension are
+// read as JSON, all others as YAML.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return NewConfigJSON(data)
+	}
+	return NewConfigYAML(data)
+}
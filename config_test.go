@@ -0,0 +1,131 @@
+package levenshtein_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/levenshtein"
+	"github.com/gnames/levenshtein/presenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	cfg := levenshtein.Config{
+		WithDiff:    true,
+		MaxEditDist: 3,
+		Pattern:     true,
+		CaseCost:    0.25,
+		Jobs:        5,
+		ChunkSize:   17,
+	}
+	fd := levenshtein.NewLevenshtein(cfg.Opts()...)
+	assert.Equal(t, cfg, fd.Config())
+
+	fd2 := levenshtein.NewLevenshtein(fd.Opts()...)
+	assert.Equal(t, cfg, fd2.Config())
+
+	// defaults are filled in.
+	cfg = levenshtein.NewLevenshtein().Config()
+	assert.Greater(t, cfg.Jobs, 0)
+	assert.Greater(t, cfg.ChunkSize, 0)
+	assert.Equal(t, 0, cfg.MaxEditDist)
+}
+
+func TestLoadConfig(t *testing.T) {
+	exp := levenshtein.Config{
+		WithDiff:      true,
+		MaxEditDist:   2,
+		CaseCost:      0.5,
+		Jobs:          4,
+		ProgressEvery: 1000,
+	}
+	dir := t.TempDir()
+	testData := []struct {
+		file, data string
+		err        bool
+	}{
+		{"cfg.json", `{"withDiff": true, "maxEditDistance": 2, "caseCost": 0.5,
+			"jobs": 4, "progressEvery": 1000}`, false},
+		{"cfg.yaml", "withDiff: true\nmaxEditDistance: 2\ncaseCost: 0.5\n" +
+			"jobs: 4\nprogressEvery: 1000\n", false},
+		{"cfg.yml", "withDiff: true\nmaxEditDistance: 2\ncaseCost: 0.5\n" +
+			"jobs: 4\nprogressEvery: 1000\n", false},
+		{"bad.json", `{"withDiff": true, "maxEditDist": 2}`, true},
+		{"bad.yaml", "withDif: true\n", true},
+		{"neg.json", `{"caseCost": -0.5}`, true},
+		{"nan.yaml", "caseCost: .nan\n", true},
+	}
+	for _, v := range testData {
+		path := filepath.Join(dir, v.file)
+		assert.Nil(t, os.WriteFile(path, []byte(v.data), 0644))
+		cfg, err := levenshtein.LoadConfig(path)
+		if v.err {
+			assert.NotNil(t, err, v.file)
+			continue
+		}
+		assert.Nil(t, err, v.file)
+		assert.Equal(t, exp, cfg, v.file)
+	}
+
+	cfg, err := levenshtein.NewConfigYAML(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, levenshtein.Config{}, cfg)
+
+	_, err = levenshtein.LoadConfig(filepath.Join(dir, "none.json"))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, levenshtein.Config{CaseCost: 0.25}.Validate())
+	assert.NotNil(t, levenshtein.Config{CaseCost: -1}.Validate())
+}
+
+// TestCompareMultOpts checks that batch methods give the same results as
+// Compare under every option.
+func TestCompareMultOpts(t *testing.T) {
+	str := fuzzyPairs(t, 1)[:2000]
+	str = append(str,
+		levenshtein.Strings{String1: "[ck]ristata", String2: "Cristata"},
+		levenshtein.Strings{String1: "Pom?tomus", String2: "pomatomus"},
+	)
+	optsData := [][]levenshtein.Option{
+		nil,
+		{levenshtein.OptWithDiff(true)},
+		{levenshtein.OptMaxEditDist(1)},
+		{levenshtein.OptMaxEditDist(2), levenshtein.OptWithDiff(true)},
+		{levenshtein.OptPattern(true)},
+		{levenshtein.OptPattern(true), levenshtein.OptMaxEditDist(1)},
+		{levenshtein.OptPattern(true), levenshtein.OptWithDiff(true)},
+		{levenshtein.OptCaseCost(0.25)},
+		{levenshtein.OptCaseCost(0.25), levenshtein.OptWithDiff(true)},
+		{levenshtein.OptCaseCost(0.25), levenshtein.OptMaxEditDist(1)},
+		{levenshtein.OptJobs(3), levenshtein.OptChunkSize(7),
+			levenshtein.OptMaxEditDist(1)},
+	}
+	for i, opts := range optsData {
+		msg := fmt.Sprintf("options set %d", i)
+		fd := levenshtein.NewLevenshtein(opts...)
+		exp := make([]presenter.Output, len(str))
+		for j, v := range str {
+			exp[j] = fd.Compare(v.String1, v.String2)
+		}
+
+		assert.Equal(t, exp, fd.CompareMult(str), msg)
+
+		res, err := fd.CompareMultContext(context.Background(), str)
+		assert.Nil(t, err, msg)
+		assert.Equal(t, exp, res, msg)
+
+		chIn := make(chan levenshtein.Strings, len(str))
+		for _, v := range str {
+			chIn <- v
+		}
+		close(chIn)
+		res = res[:0]
+		for v := range fd.CompareStream(context.Background(), chIn) {
+			res = append(res, v)
+		}
+		assert.Equal(t, exp, res, msg)
+	}
+}
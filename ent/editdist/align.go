@@ -0,0 +1,321 @@
+package editdist
+
+import "math"
+
+// negInf is used as minus infinity in alignment matrices. It is far enough
+// from math.MinInt to prevent overflows when scores are added to it.
+const negInf = math.MinInt / 4
+
+// alignment states of the affine gap algorithms.
+const (
+	stMatch uint8 = iota
+	stGap1
+	stGap2
+	// stStart marks a beginning of a local alignment.
+	stStart
+)
+
+// Scoring contains scores used by alignment algorithms. Alignments
+// maximize the total score, so Mismatch, GapOpen and GapExtend are
+// normally negative numbers.
+type Scoring struct {
+	// Match is a score of two identical runes aligned to each other.
+	Match int
+	// Mismatch is a score of two different runes aligned to each other.
+	Mismatch int
+	// GapOpen is a score of the first rune of a gap.
+	GapOpen int
+	// GapExtend is a score of every following rune of the same gap. A gap
+	// of k runes has a score of GapOpen + (k-1)*GapExtend.
+	GapExtend int
+	// Subst, if not nil, overrides Match and Mismatch scores and allows
+	// to use a substitution matrix.
+	Subst func(r1, r2 rune) int
+}
+
+// DefaultScoring returns scores that favor one long gap over several
+// scattered edits.
+func DefaultScoring() Scoring {
+	return Scoring{Match: 2, Mismatch: -1, GapOpen: -3, GapExtend: -1}
+}
+
+func (s Scoring) score(r1, r2 rune) int {
+	if s.Subst != nil {
+		return s.Subst(r1, r2)
+	}
+	if r1 == r2 {
+		return s.Match
+	}
+	return s.Mismatch
+}
+
+// Alignment is a result of a global alignment of two strings.
+type Alignment struct {
+	// Score is the total score of the alignment.
+	Score int
+	// Aligned1 is the first string with gaps marked by '-'.
+	Aligned1 string
+	// Aligned2 is the second string with gaps marked by '-'.
+	Aligned2 string
+	// Tags1 is the first string with differences tagged the same way
+	// as by ComputeDistance.
+	Tags1 string
+	// Tags2 is the second string with differences tagged the same way
+	// as by ComputeDistance.
+	Tags2 string
+}
+
+// AlignGlobal aligns two strings using Needleman-Wunsch algorithm with
+// affine gap penalties (Gotoh's variant). Unlike Levenshtein distance,
+// a run of k inserted runes is scored as one gap, so a missing syllable
+// costs less than k scattered typos.
+func AlignGlobal(a, b string, sc Scoring) Alignment {
+	s1 := []rune(a)
+	s2 := []rune(b)
+	m := newAffine(s1, s2, sc, false)
+	st := m.best(len(s1), len(s2))
+	score := m.value(st, len(s1), len(s2))
+	events := m.traceBack(st, len(s1), len(s2))
+	res := Alignment{Score: score}
+	res.Aligned1, res.Aligned2 = aligned(s1, s2, events)
+	res.Tags1, res.Tags2 = diffs(s1, s2, events)
+	return res
+}
+
+// affine keeps matrices of Gotoh's algorithm. Matrix mt keeps the best
+// scores of alignments that end with two aligned runes, g1 of alignments
+// that end with a rune of the first string against a gap, g2 of
+// alignments that end with a rune of the second string against a gap.
+// The pointer matrices keep the state of the previous cell. For local
+// alignments the aligned runes state can also start a new alignment.
+type affine struct {
+	s1, s2        []rune
+	sc            Scoring
+	local         bool
+	rl            int
+	mt, g1, g2    []int
+	pMt, pG1, pG2 []uint8
+}
+
+func newAffine(s1, s2 []rune, sc Scoring, local bool) *affine {
+	rl := len(s2) + 1
+	size := (len(s1) + 1) * rl
+	m := &affine{
+		s1: s1, s2: s2, sc: sc, local: local, rl: rl,
+		mt:  make([]int, size),
+		g1:  make([]int, size),
+		g2:  make([]int, size),
+		pMt: make([]uint8, size),
+		pG1: make([]uint8, size),
+		pG2: make([]uint8, size),
+	}
+	m.fill()
+	return m
+}
+
+func (m *affine) fill() {
+	rl := m.rl
+	open, ext := m.sc.GapOpen, m.sc.GapExtend
+	for i := 0; i <= len(m.s1); i++ {
+		for j := 0; j <= len(m.s2); j++ {
+			k := i*rl + j
+			m.mt[k], m.g1[k], m.g2[k] = negInf, negInf, negInf
+			if i == 0 && j == 0 {
+				m.mt[k] = 0
+				continue
+			}
+			if i > 0 && j > 0 {
+				d := k - rl - 1
+				st, v := maxState(m.mt[d], m.g1[d], m.g2[d])
+				if m.local && v <= 0 {
+					st, v = stStart, 0
+				}
+				m.mt[k] = addScore(v, m.sc.score(m.s1[i-1], m.s2[j-1]))
+				m.pMt[k] = st
+			}
+			if i > 0 {
+				u := k - rl
+				m.pG1[k], m.g1[k] = maxState(
+					addScore(m.mt[u], open),
+					addScore(m.g1[u], ext),
+					addScore(m.g2[u], open),
+				)
+			}
+			if j > 0 {
+				l := k - 1
+				m.pG2[k], m.g2[k] = maxState(
+					addScore(m.mt[l], open),
+					addScore(m.g1[l], open),
+					addScore(m.g2[l], ext),
+				)
+			}
+		}
+	}
+}
+
+// addScore adds a score to a matrix value, keeping minus infinity intact.
+func addScore(v, score int) int {
+	if v == negInf {
+		return negInf
+	}
+	return v + score
+}
+
+func maxState(mt, g1, g2 int) (uint8, int) {
+	st, v := stMatch, mt
+	if g1 > v {
+		st, v = stGap1, g1
+	}
+	if g2 > v {
+		st, v = stGap2, g2
+	}
+	return st, v
+}
+
+// best returns a state with the highest score in the cell.
+func (m *affine) best(i, j int) uint8 {
+	k := i*m.rl + j
+	st, _ := maxState(m.mt[k], m.g1[k], m.g2[k])
+	return st
+}
+
+func (m *affine) value(st uint8, i, j int) int {
+	k := i*m.rl + j
+	switch st {
+	case stGap1:
+		return m.g1[k]
+	case stGap2:
+		return m.g2[k]
+	default:
+		return m.mt[k]
+	}
+}
+
+// traceBack collects edit events in reverse order, starting from the
+// cell (i, j) in the state st. It stops at the beginning of the strings,
+// or at the beginning of a local alignment.
+func (m *affine) traceBack(st uint8, i, j int) []eventType {
+	events := make([]eventType, 0, len(m.s1)+len(m.s2))
+	for st != stStart && (i > 0 || j > 0) {
+		k := i*m.rl + j
+		switch st {
+		case stGap1:
+			// rune of the first string does not exist in the second one.
+			events = append(events, ins)
+			st = m.pG1[k]
+			i--
+		case stGap2:
+			// rune of the second string does not exist in the first one.
+			events = append(events, del)
+			st = m.pG2[k]
+			j--
+		default:
+			if m.s1[i-1] == m.s2[j-1] {
+				events = append(events, same)
+			} else {
+				events = append(events, subst)
+			}
+			st = m.pMt[k]
+			i--
+			j--
+		}
+	}
+	return events
+}
+
+// aligned shows aligned strings with gaps marked as '-'.
+func aligned(s1, s2 []rune, events []eventType) (string, string) {
+	a1 := make([]rune, 0, len(events))
+	a2 := make([]rune, 0, len(events))
+	var i, j int
+	for k := len(events) - 1; k >= 0; k-- {
+		switch events[k] {
+		case ins:
+			a1 = append(a1, s1[i])
+			a2 = append(a2, '-')
+			i++
+		case del:
+			a1 = append(a1, '-')
+			a2 = append(a2, s2[j])
+			j++
+		default:
+			a1 = append(a1, s1[i])
+			a2 = append(a2, s2[j])
+			i++
+			j++
+		}
+	}
+	return string(a1), string(a2)
+}
+
+// LocalAlignment is a result of a local alignment of two strings.
+// Offsets are given in runes, start offsets are inclusive, end offsets
+// are exclusive.
+type LocalAlignment struct {
+	// Score is the score of the best local alignment. It is 0 if strings
+	// have nothing in common.
+	Score int
+	// Start1 is the offset of the aligned segment in the first string.
+	Start1 int
+	// End1 is the end of the aligned segment in the first string.
+	End1 int
+	// Start2 is the offset of the aligned segment in the second string.
+	Start2 int
+	// End2 is the end of the aligned segment in the second string.
+	End2 int
+	// Segment1 is the aligned segment of the first string.
+	Segment1 string
+	// Segment2 is the aligned segment of the second string.
+	Segment2 string
+	// Tags1 is the first string where the aligned segment is enclosed
+	// into <align> tag, and differences inside the segment are tagged
+	// the same way as by ComputeDistance.
+	Tags1 string
+	// Tags2 is the second string tagged the same way as Tags1.
+	Tags2 string
+}
+
+// AlignLocal finds the best-scoring local alignment of two strings using
+// Smith-Waterman algorithm with affine gaps. It is useful for comparing
+// partial or truncated strings with full ones. For local alignments
+// Match has to be positive, and the other scores have to be negative.
+func AlignLocal(a, b string, sc Scoring) LocalAlignment {
+	s1 := []rune(a)
+	s2 := []rune(b)
+	m := newAffine(s1, s2, sc, true)
+
+	var res LocalAlignment
+	var bestI, bestJ int
+	for i := 1; i <= len(s1); i++ {
+		for j := 1; j <= len(s2); j++ {
+			if v := m.mt[i*m.rl+j]; v > res.Score {
+				res.Score, bestI, bestJ = v, i, j
+			}
+		}
+	}
+	if res.Score == 0 {
+		res.Tags1, res.Tags2 = a, b
+		return res
+	}
+
+	events := m.traceBack(stMatch, bestI, bestJ)
+	res.End1, res.End2 = bestI, bestJ
+	res.Start1, res.Start2 = bestI, bestJ
+	for _, e := range events {
+		if e != del {
+			res.Start1--
+		}
+		if e != ins {
+			res.Start2--
+		}
+	}
+	seg1 := s1[res.Start1:res.End1]
+	seg2 := s2[res.Start2:res.End2]
+	res.Segment1, res.Segment2 = string(seg1), string(seg2)
+	d1, d2 := diffs(seg1, seg2, events)
+	res.Tags1 = string(s1[:res.Start1]) + "<align>" + d1 + "</align>" +
+		string(s1[res.End1:])
+	res.Tags2 = string(s2[:res.Start2]) + "<align>" + d2 + "</align>" +
+		string(s2[res.End2:])
+	return res
+}
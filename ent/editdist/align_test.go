@@ -0,0 +1,88 @@
+package editdist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlignGlobal(t *testing.T) {
+	testData := []struct {
+		str1, str2 string
+		score      int
+		a1, a2     string
+		t1, t2     string
+	}{
+		{"Pomatomus", "Pomus", 4, "Pomatomus", "P----omus",
+			"P<ins>omat</ins>omus", "P<del>omat</del>omus"},
+		{"Pomus", "Pomatomus", 4, "P----omus", "Pomatomus",
+			"P<del>omat</del>omus", "P<ins>omat</ins>omus"},
+		{"Aablyseius", "Amblyseius", 17, "Aablyseius", "Amblyseius",
+			"A<subst>a</subst>blyseius", "A<subst>m</subst>blyseius"},
+		{"Boston", "Chicago", -9, "-Boston", "Chicago",
+			"<del>C</del><subst>Boston</subst>", "<ins>C</ins><subst>hicago</subst>"},
+		{"abc", "", -5, "abc", "---", "<ins>abc</ins>", "<del>abc</del>"},
+		{"", "abc", -5, "---", "abc", "<del>abc</del>", "<ins>abc</ins>"},
+		{"", "", 0, "", "", "", ""},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		res := editdist.AlignGlobal(v.str1, v.str2, editdist.DefaultScoring())
+		assert.Equal(t, v.score, res.Score, msg)
+		assert.Equal(t, v.a1, res.Aligned1, msg)
+		assert.Equal(t, v.a2, res.Aligned2, msg)
+		assert.Equal(t, v.t1, res.Tags1, msg)
+		assert.Equal(t, v.t2, res.Tags2, msg)
+	}
+}
+
+func TestAlignGlobalAffine(t *testing.T) {
+	sc := editdist.DefaultScoring()
+	// one gap of 4 runes is better than 4 scattered gaps.
+	res := editdist.AlignGlobal("Pomatomus", "Pomus", sc)
+	assert.Equal(t, 5*sc.Match+sc.GapOpen+3*sc.GapExtend, res.Score)
+
+	sc.Subst = func(r1, r2 rune) int {
+		if r1 == r2 || (r1 == 'c' && r2 == 'k') || (r1 == 'k' && r2 == 'c') {
+			return 2
+		}
+		return -1
+	}
+	res = editdist.AlignGlobal("cristata", "kristata", sc)
+	assert.Equal(t, 16, res.Score)
+	assert.Equal(t, "<subst>c</subst>ristata", res.Tags1)
+}
+
+func TestAlignLocal(t *testing.T) {
+	testData := []struct {
+		str1, str2   string
+		score        int
+		start1, end1 int
+		start2, end2 int
+		t1, t2       string
+	}{
+		{"Pomatomus saltator", "atomus salator L.", 25, 3, 18, 0, 14,
+			"Pom<align>atomus sal<ins>t</ins>ator</align>",
+			"<align>atomus sal<del>t</del>ator</align> L."},
+		{"Pomatomus saltator (Linnaeus, 1766)", "Pomatomus", 18, 0, 9, 0, 9,
+			"<align>Pomatomus</align> saltator (Linnaeus, 1766)",
+			"<align>Pomatomus</align>"},
+		{"abc", "xyz", 0, 0, 0, 0, 0, "abc", "xyz"},
+		{"", "", 0, 0, 0, 0, 0, "", ""},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		res := editdist.AlignLocal(v.str1, v.str2, editdist.DefaultScoring())
+		assert.Equal(t, v.score, res.Score, msg)
+		assert.Equal(t, v.start1, res.Start1, msg)
+		assert.Equal(t, v.end1, res.End1, msg)
+		assert.Equal(t, v.start2, res.Start2, msg)
+		assert.Equal(t, v.end2, res.End2, msg)
+		assert.Equal(t, v.t1, res.Tags1, msg)
+		assert.Equal(t, v.t2, res.Tags2, msg)
+	}
+}
@@ -0,0 +1,74 @@
+package editdist
+
+// trimAffixes removes common prefix and suffix from two rune slices. It
+// returns the remaining parts and lengths of the removed prefix and
+// suffix.
+func trimAffixes(s1, s2 []rune) ([]rune, []rune, int, int) {
+	var pre, suf int
+	l := minInt(len(s1), len(s2))
+	for pre < l && s1[pre] == s2[pre] {
+		pre++
+	}
+	l -= pre
+	for suf < l && s1[len(s1)-suf-1] == s2[len(s2)-suf-1] {
+		suf++
+	}
+	return s1[pre : len(s1)-suf], s2[pre : len(s2)-suf], pre, suf
+}
+
+// lowerBound returns a cheap estimate that edit distance between two
+// strings cannot be smaller than. It uses difference in lengths and
+// difference in character histograms. Every edit operation changes the
+// count of at most one surplus and one missing character, so edit
+// distance cannot be smaller than the larger of these sums.
+func lowerBound(s1, s2 []rune) int {
+	lenDiff := len(s1) - len(s2)
+	if lenDiff < 0 {
+		lenDiff = -lenDiff
+	}
+
+	var surplus, missing int
+	if isASCII(s1) && isASCII(s2) {
+		var hist [128]int
+		for _, r := range s1 {
+			hist[r]++
+		}
+		for _, r := range s2 {
+			hist[r]--
+		}
+		for _, v := range hist {
+			if v > 0 {
+				surplus += v
+			} else {
+				missing -= v
+			}
+		}
+	} else {
+		hist := make(map[rune]int, len(s1))
+		for _, r := range s1 {
+			hist[r]++
+		}
+		for _, r := range s2 {
+			hist[r]--
+		}
+		for _, v := range hist {
+			if v > 0 {
+				surplus += v
+			} else {
+				missing -= v
+			}
+		}
+	}
+
+	res := max(surplus, missing)
+	return max(res, lenDiff)
+}
+
+func isASCII(s []rune) bool {
+	for _, r := range s {
+		if r >= 128 || r < 0 {
+			return false
+		}
+	}
+	return true
+}
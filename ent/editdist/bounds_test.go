@@ -0,0 +1,63 @@
+package editdist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBounds(t *testing.T) {
+	testData := []struct {
+		str1, str2 string
+		max        int
+		dist       int
+		abort      bool
+	}{
+		{"Aablyseius hibisci", "Amblyseius hibisci", 1, 1, false},
+		{"Aablyseius hibisci", "Amblyseius hibisci", 2, 1, false},
+		// length difference
+		{"Pomatomus", "Pomatomus saltator", 3, 3, true},
+		// histogram difference
+		{"abcd", "wxyz", 3, 3, true},
+		{"абвг", "абвгд", 1, 1, false},
+		{"абвг", "абвгде", 1, 1, true},
+		// final distance exceeds max without exceeding row minimum.
+		{"abc", "xab", 1, 1, true},
+		{"abc", "xab", 2, 2, false},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		dist, ab := editdist.ComputeDistanceMax(v.str1, v.str2, v.max)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.abort, ab, msg)
+	}
+}
+
+func TestTrimmedDiff(t *testing.T) {
+	testData := []struct {
+		str1, str2 string
+		dist       int
+		d1, d2     string
+	}{
+		{"Aablyseius hibisci", "Amblyseius hibisci", 1,
+			"A<subst>a</subst>blyseius hibisci",
+			"A<subst>m</subst>blyseius hibisci"},
+		{"Pomatomus", "Pomatomus saltator", 9,
+			"Pomatomus<del> saltator</del>",
+			"Pomatomus<ins> saltator</ins>"},
+		{"saltator", "Pomatomus saltator", 10,
+			"<del>Pomatomus </del>saltator",
+			"<ins>Pomatomus </ins>saltator"},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		dist, d1, d2 := editdist.ComputeDistance(v.str1, v.str2, true)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.d1, d1, msg)
+		assert.Equal(t, v.d2, d2, msg)
+	}
+}
@@ -0,0 +1,72 @@
+package editdist
+
+import "sync"
+
+// maxPooledMatrix is the largest matrix that is kept in pooled buffers,
+// so a few comparisons of very long strings do not hold memory forever.
+const maxPooledMatrix = 1 << 20
+
+// bufPool keeps buffers for package-level functions.
+var bufPool = sync.Pool{
+	New: func() any { return new(Buffer) },
+}
+
+func getBuffer() *Buffer {
+	return bufPool.Get().(*Buffer)
+}
+
+func putBuffer(buf *Buffer) {
+	if cap(buf.matrix) > maxPooledMatrix {
+		return
+	}
+	bufPool.Put(buf)
+}
+
+// Buffer keeps scratch memory for edit distance calculations. Memory
+// grows as needed and is reused by the next calculation, so a long series
+// of comparisons does not allocate, except for tagged strings of diffs.
+// Results of methods of Buffer are the same as results of functions with
+// the same names. Buffer is not safe for concurrent use, every goroutine
+// needs its own one. Zero value is ready to use.
+type Buffer struct {
+	s1, s2 []rune
+	row    []uint8
+	matrix []uint8
+	events []eventType
+	tags   []byte
+}
+
+// runes converts two strings to runes, using memory of the buffer.
+func (buf *Buffer) runes(a, b string) ([]rune, []rune) {
+	buf.s1 = appendRunes(buf.s1[:0], a)
+	buf.s2 = appendRunes(buf.s2[:0], b)
+	return buf.s1, buf.s2
+}
+
+// newRow returns a DP row of n elements.
+func (buf *Buffer) newRow(n int) []uint8 {
+	if cap(buf.row) < n {
+		buf.row = make([]uint8, n)
+	}
+	return buf.row[:n]
+}
+
+// newMatrix returns an empty DP matrix with capacity for n elements.
+func (buf *Buffer) newMatrix(n int) []uint8 {
+	if cap(buf.matrix) < n {
+		buf.matrix = make([]uint8, 0, n)
+	}
+	return buf.matrix[:0]
+}
+
+// appendRunes appends runes of a string to dst. The number of bytes is
+// the upper limit of the number of runes, so dst grows at most once.
+func appendRunes(dst []rune, s string) []rune {
+	if cap(dst)-len(dst) < len(s) {
+		dst = append(make([]rune, 0, len(dst)+len(s)), dst...)
+	}
+	for _, r := range s {
+		dst = append(dst, r)
+	}
+	return dst
+}
@@ -0,0 +1,96 @@
+package editdist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuffer runs one buffer through long, short and long strings again,
+// so data left from a previous call would break the results.
+func TestBuffer(t *testing.T) {
+	testData := []struct {
+		str1, str2   string
+		dist         int
+		tags1, tags2 string
+		stats        editdist.Stats
+		max2         int
+		aborted      bool
+	}{
+		{"Pomatomus saltator (Linnaeus, 1766)", "Pomatomus saltatrix (L., 1766)",
+			10,
+			"Pomatomus saltat<ins>o</ins>r<ins> (L</ins>i<ins>n</ins>" +
+				"<subst>naeus</subst>, 1766)",
+			"Pomatomus saltat<del>o</del>r<del> (L</del>i<del>n</del>" +
+				"<subst>x (L.</subst>, 1766)",
+			editdist.Stats{Substitutions: 5, Insertions: 5,
+				CommonPrefix: 16, CommonSuffix: 7, FirstDiff: 17},
+			2, true},
+		{"Pumé", "Puma", 1, "Pum<subst>é</subst>", "Pum<subst>a</subst>",
+			editdist.Stats{Substitutions: 1, CommonPrefix: 3, FirstDiff: 4},
+			1, false},
+		{"", "abc", 3, "<del>abc</del>", "<ins>abc</ins>",
+			editdist.Stats{Deletions: 3, FirstDiff: 1}, 2, true},
+		{"abc", "", 3, "<ins>abc</ins>", "<del>abc</del>",
+			editdist.Stats{Insertions: 3, FirstDiff: 1}, 2, true},
+		{"Pomatomus saltator (Linnaeus, 1766)", "Pomatomus", 26,
+			"Pomatomus<ins> saltator (Linnaeus, 1766)</ins>",
+			"Pomatomus<del> saltator (Linnaeus, 1766)</del>",
+			editdist.Stats{Insertions: 26, CommonPrefix: 9, FirstDiff: 10},
+			2, true},
+		{"Pumé", "Puma", 1, "Pum<subst>é</subst>", "Pum<subst>a</subst>",
+			editdist.Stats{Substitutions: 1, CommonPrefix: 3, FirstDiff: 4},
+			1, false},
+		{"Pomatomus saltator (Linnaeus, 1766)", "Pomatomus saltatrix (L., 1766)",
+			10,
+			"Pomatomus saltat<ins>o</ins>r<ins> (L</ins>i<ins>n</ins>" +
+				"<subst>naeus</subst>, 1766)",
+			"Pomatomus saltat<del>o</del>r<del> (L</del>i<del>n</del>" +
+				"<subst>x (L.</subst>, 1766)",
+			editdist.Stats{Substitutions: 5, Insertions: 5,
+				CommonPrefix: 16, CommonSuffix: 7, FirstDiff: 17},
+			2, true},
+	}
+
+	var buf editdist.Buffer
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		dist, t1, t2 := buf.ComputeDistance(v.str1, v.str2, true)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.tags1, t1, msg)
+		assert.Equal(t, v.tags2, t2, msg)
+
+		dist, _, _ = buf.ComputeDistance(v.str1, v.str2, false)
+		assert.Equal(t, v.dist, dist, msg)
+
+		dist, t1, t2, st := buf.ComputeDistanceStats(v.str1, v.str2)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.tags1, t1, msg)
+		assert.Equal(t, v.tags2, t2, msg)
+		assert.Equal(t, v.stats, st, msg)
+
+		dist, aborted := buf.ComputeDistanceMax(v.str1, v.str2, 2)
+		assert.Equal(t, v.max2, dist, msg)
+		assert.Equal(t, v.aborted, aborted, msg)
+	}
+}
+
+func TestBufferAllocs(t *testing.T) {
+	var buf editdist.Buffer
+	a, b := "Pomatomus solatror", "Pomatomus saltator"
+	// the first call grows the buffer.
+	buf.ComputeDistanceStats(a, b)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.ComputeDistance(a, b, false)
+		buf.ComputeDistanceMax(a, b, 1)
+	})
+	assert.Equal(t, 0.0, allocs)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		buf.ComputeDistanceStats(a, b)
+	})
+	assert.LessOrEqual(t, allocs, 2.0)
+}
@@ -0,0 +1,217 @@
+package editdist
+
+import (
+	"math"
+	"unicode"
+)
+
+// epsilon is used to compare accumulated costs during traceback.
+const epsilon = 1e-9
+
+// isCaseChange returns true if two runes differ only by case.
+func isCaseChange(r1, r2 rune) bool {
+	return r1 != r2 &&
+		(unicode.ToLower(r1) == unicode.ToLower(r2) ||
+			unicode.ToUpper(r1) == unicode.ToUpper(r2))
+}
+
+// ComputeDistanceCase computes edit distance between two strings where
+// a substitution of runes that differ only by case costs caseCost instead
+// of 1. Such substitutions are marked by <case> tag in the diff strings,
+// so capitalization problems can be told apart from spelling errors.
+// It returns the weighted cost together with the usual Levenshtein
+// distance, where every substitution costs 1. Both are calculated during
+// the same pass.
+func ComputeDistanceCase(
+	a, b string,
+	caseCost float64,
+	diff bool,
+) (float64, int, string, string) {
+	s1 := []rune(a)
+	s2 := []rune(b)
+	cost, dist, events := distanceCase(s1, s2, caseCost, diff)
+	if !diff {
+		return cost, dist, "", ""
+	}
+	d1, d2 := diffs(s1, s2, events)
+	return cost, dist, d1, d2
+}
+
+// ComputeDistanceCaseStats computes edit distance the same way as
+// ComputeDistanceCase with the diff flag set to true. It also returns
+// statistics of edit operations found during traceback.
+func ComputeDistanceCaseStats(
+	a, b string,
+	caseCost float64,
+) (float64, int, string, string, Stats) {
+	s1 := []rune(a)
+	s2 := []rune(b)
+	cost, dist, events := distanceCase(s1, s2, caseCost, true)
+	d1, d2 := diffs(s1, s2, events)
+	return cost, dist, d1, d2, runeStats(s1, s2, events)
+}
+
+// distanceCase calculates edit distance with reduced cost of case
+// changes. The plain edit distance is kept in a separate row of the same
+// loop. If diff is true, it also returns edit events in reverse order.
+// The events describe an alignment with the lowest cost, and among such
+// alignments the one with the fewest edits. Such alignment can still have
+// more edits than the plain edit distance.
+func distanceCase(
+	s1, s2 []rune,
+	caseCost float64,
+	diff bool,
+) (float64, int, []eventType) {
+	lenS1 := len(s1)
+	lenS2 := len(s2)
+	rl := lenS1 + 1
+
+	var m []float64
+	var mu []int
+	var z []int
+	if diff {
+		m = make([]float64, 0, rl*(lenS2+1))
+		mu = make([]int, 0, rl*(lenS2+1))
+		z = make([]int, rl)
+	}
+
+	x := make([]float64, rl)
+	y := make([]int, rl)
+	for i := 1; i < rl; i++ {
+		x[i] = float64(i)
+		y[i] = i
+	}
+	if diff {
+		copy(z, y)
+		m = append(m, x...)
+		mu = append(mu, z...)
+	}
+
+	for i := 1; i <= lenS2; i++ {
+		prev := float64(i)
+		prevDist := i
+		prevEdits := i
+		for j := 1; j <= lenS1; j++ {
+			current := x[j-1] // match
+			currentDist := y[j-1]
+			var currentEdits int
+			if s2[i-1] != s1[j-1] {
+				cost := 1.0
+				if isCaseChange(s2[i-1], s1[j-1]) {
+					cost = caseCost
+				}
+				current = math.Min(
+					x[j-1]+cost, // substitution
+					math.Min(prev+1, // insertion
+						x[j]+1), // deletion
+				)
+				currentDist = minInt(minInt(y[j-1], prevDist), y[j]) + 1
+			}
+			if diff {
+				currentEdits = minEdits(current, s2[i-1] == s1[j-1],
+					x[j-1]+caseOrOne(s1[j-1], s2[i-1], caseCost), z[j-1],
+					prev+1, prevEdits,
+					x[j]+1, z[j],
+				)
+				z[j-1] = prevEdits
+				prevEdits = currentEdits
+			}
+			x[j-1] = prev
+			prev = current
+			y[j-1] = prevDist
+			prevDist = currentDist
+		}
+		x[lenS1] = prev
+		y[lenS1] = prevDist
+		if diff {
+			z[lenS1] = prevEdits
+			m = append(m, x...)
+			mu = append(mu, z...)
+		}
+	}
+
+	if !diff {
+		return x[lenS1], y[lenS1], nil
+	}
+	return x[lenS1], y[lenS1], traceBackCase(s1, s2, m, mu, caseCost)
+}
+
+// caseOrOne returns the cost of substitution of r2 by r1.
+func caseOrOne(r1, r2 rune, caseCost float64) float64 {
+	if isCaseChange(r1, r2) {
+		return caseCost
+	}
+	return 1
+}
+
+// minEdits returns the smallest number of edits among the moves that
+// reach the cell with the lowest cost. The match move keeps the number of
+// edits of the diagonal cell, the other moves add one edit to it.
+func minEdits(
+	cost float64,
+	match bool,
+	substCost float64, diagEdits int,
+	insCost float64, leftEdits int,
+	delCost float64, upEdits int,
+) int {
+	if match {
+		return diagEdits
+	}
+	res := math.MaxInt
+	if eqCost(substCost, cost) {
+		res = diagEdits
+	}
+	if eqCost(insCost, cost) {
+		res = minInt(res, leftEdits)
+	}
+	if eqCost(delCost, cost) {
+		res = minInt(res, upEdits)
+	}
+	return res + 1
+}
+
+// eqCost compares accumulated costs.
+func eqCost(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+// traceBackCase collects edit events in reverse order from the matrices
+// created by distanceCase. The m matrix keeps costs, the mu matrix keeps
+// the number of edits of the cheapest alignments. A move is taken only if
+// it keeps both of them.
+func traceBackCase(
+	s1, s2 []rune,
+	m []float64,
+	mu []int,
+	caseCost float64,
+) []eventType {
+	rl := len(s1) + 1
+	events := make([]eventType, 0, len(s1)+len(s2))
+	i, j := len(s2), len(s1)
+	for i > 0 || j > 0 {
+		dist, edits := m[rl*i+j], mu[rl*i+j]
+		up, left, diag := rl*(i-1)+j, rl*i+j-1, rl*(i-1)+j-1
+		// gaps are preferred, so they are pushed to the ends of strings.
+		switch {
+		case i > 0 && eqCost(m[up]+1, dist) && mu[up]+1 == edits:
+			// rune of the second string does not exist in the first one.
+			events = append(events, del)
+			i--
+		case j > 0 && eqCost(m[left]+1, dist) && mu[left]+1 == edits:
+			// rune of the first string does not exist in the second one.
+			events = append(events, ins)
+			j--
+		case s1[j-1] == s2[i-1]:
+			events = append(events, same)
+			i, j = i-1, j-1
+		case isCaseChange(s1[j-1], s2[i-1]) &&
+			eqCost(m[diag]+caseCost, dist) && mu[diag]+1 == edits:
+			events = append(events, caseChange)
+			i, j = i-1, j-1
+		default:
+			events = append(events, subst)
+			i, j = i-1, j-1
+		}
+	}
+	return events
+}
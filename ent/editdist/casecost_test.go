@@ -0,0 +1,76 @@
+package editdist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistCase(t *testing.T) {
+	testData := []struct {
+		str1, str2 string
+		cost       float64
+		dist       int
+		d1, d2     string
+	}{
+		{"aablyseius", "Aablyseius", 0.25, 1,
+			"<case>a</case>ablyseius", "<case>A</case>ablyseius"},
+		{"aablyseius", "Amblyseius", 1.25, 2,
+			"<case>a</case><subst>a</subst>blyseius",
+			"<case>A</case><subst>m</subst>blyseius"},
+		{"POMATOMUS", "Pomatomus", 2, 8,
+			"P<case>OMATOMUS</case>", "P<case>omatomus</case>"},
+		{"Щука", "щука", 0.25, 1, "<case>Щ</case>ука", "<case>щ</case>ука"},
+		{"Pomatomus", "Pomatomus", 0, 0, "Pomatomus", "Pomatomus"},
+		{"Boston", "boston1", 1.25, 2,
+			"<case>B</case>oston<del>1</del>", "<case>b</case>oston<ins>1</ins>"},
+		{"", "ab", 2, 2, "<del>ab</del>", "<ins>ab</ins>"},
+		{"", "", 0, 0, "", ""},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		cost, dist, d1, d2 := editdist.ComputeDistanceCase(
+			v.str1, v.str2, 0.25, true,
+		)
+		assert.InDelta(t, v.cost, cost, 1e-9, msg)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.d1, d1, msg)
+		assert.Equal(t, v.d2, d2, msg)
+
+		// plain distance does not depend on the cost of case changes.
+		ed, _, _ := editdist.ComputeDistance(v.str1, v.str2, false)
+		_, dist, _, _ = editdist.ComputeDistanceCase(v.str1, v.str2, 0.9, false)
+		assert.Equal(t, ed, dist, msg)
+	}
+}
+
+func TestDistCaseEdits(t *testing.T) {
+	testData := []struct {
+		str1, str2 string
+		caseCost   float64
+		cost       float64
+		dist       int
+		edits      int
+		d1         string
+	}{
+		// the cheapest alignment needs more edits than the plain distance.
+		{"ABx", "xab", 0.25, 2.5, 3, 4, "<del>x</del><case>AB</case><ins>x</ins>"},
+		// among the cheapest alignments the one with fewer edits is taken.
+		{"A", "a", 2, 2, 1, 1, "<case>A</case>"},
+		{"AbC", "cBa", 2, 4, 3, 3, "<subst>A</subst><case>b</case><subst>C</subst>"},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		cost, dist, d1, _, st := editdist.ComputeDistanceCaseStats(
+			v.str1, v.str2, v.caseCost,
+		)
+		assert.InDelta(t, v.cost, cost, 1e-9, msg)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.edits, st.Substitutions+st.Insertions+st.Deletions, msg)
+		assert.Equal(t, v.d1, d1, msg)
+	}
+}
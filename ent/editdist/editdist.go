@@ -18,6 +18,7 @@ const (
 	subst
 	ins
 	del
+	caseChange
 )
 
 func (e eventType) String() string {
@@ -28,6 +29,8 @@ func (e eventType) String() string {
 		return "ins"
 	case del:
 		return "del"
+	case caseChange:
+		return "case"
 	default:
 		return ""
 	}
@@ -36,8 +39,22 @@ func (e eventType) String() string {
 // ComputeDistanceMax computes the levenshtein distance between the two
 // strings passed as an argument. It stops execution if edit distance grows
 // a certain max value. It returns edit distance and a boolean. The boolean is
-// true when calculation was aborted by the `max` value.
+// true, and the returned distance is `max`, whenever the distance is larger
+// than `max`, including the case when only the last row of the matrix
+// exceeds it. Pairs that cannot be within the `max` distance according to
+// their lengths or characters are rejected without calculating the distance.
 func ComputeDistanceMax(a, b string, max int) (int, bool) {
+	if a == b {
+		return 0, false
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	return buf.ComputeDistanceMax(a, b, max)
+}
+
+// ComputeDistanceMax works like the ComputeDistanceMax function, reusing
+// memory of the buffer.
+func (buf *Buffer) ComputeDistanceMax(a, b string, max int) (int, bool) {
 	if len(a) == 0 {
 		dist := utf8.RuneCountInString(b)
 		if max > 0 && dist > max {
@@ -62,8 +79,23 @@ func ComputeDistanceMax(a, b string, max int) (int, bool) {
 	// This could be avoided by using utf8.RuneCountInString
 	// and then doing some juggling with rune indices,
 	// but leads to far more bounds checks. It is a reasonable trade-off.
-	s1 := []rune(a)
-	s2 := []rune(b)
+	s1, s2 := buf.runes(a, b)
+
+	// common prefix and suffix do not change edit distance.
+	s1, s2, _, _ = trimAffixes(s1, s2)
+
+	// reject pairs that cannot be within max distance without running DP.
+	if max > 0 && lowerBound(s1, s2) > max {
+		return max, true
+	}
+
+	if len(s1) == 0 || len(s2) == 0 {
+		dist := len(s1) + len(s2)
+		if max > 0 && dist > max {
+			return max, true
+		}
+		return dist, false
+	}
 
 	// swap to save some memory O(min(a,b)) instead of O(a)
 	if len(s1) > len(s2) {
@@ -73,8 +105,9 @@ func ComputeDistanceMax(a, b string, max int) (int, bool) {
 	lenS2 := len(s2)
 
 	// init the row
-	x := make([]uint8, lenS1+1)
+	x := buf.newRow(lenS1 + 1)
 	// we start from 1 because index 0 is already 0.
+	x[0] = 0
 	for i := 1; i < len(x); i++ {
 		x[i] = uint8(i)
 	}
@@ -108,7 +141,11 @@ func ComputeDistanceMax(a, b string, max int) (int, bool) {
 		}
 		x[lenS1] = prev
 	}
-	return int(x[lenS1]), false
+	dist := int(x[lenS1])
+	if max > 0 && dist > max {
+		return max, true
+	}
+	return dist, false
 }
 
 // ComputeDistance computes the levenshtein distance between the two
@@ -120,6 +157,20 @@ func ComputeDistance(a, b string, diff bool) (int, string, string) {
 	if a == b {
 		return 0, a, b
 	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	return buf.ComputeDistance(a, b, diff)
+}
+
+// ComputeDistance works like the ComputeDistance function, reusing memory
+// of the buffer. Only tagged strings are allocated if diff is true.
+func (buf *Buffer) ComputeDistance(
+	a, b string,
+	diff bool,
+) (int, string, string) {
+	if a == b {
+		return 0, a, b
+	}
 
 	if len(a) == 0 {
 		return utf8.RuneCountInString(b),
@@ -137,9 +188,94 @@ func ComputeDistance(a, b string, diff bool) (int, string, string) {
 	// This could be avoided by using utf8.RuneCountInString
 	// and then doing some juggling with rune indices,
 	// but leads to far more bounds checks. It is a reasonable trade-off.
-	s1 := []rune(a)
-	s2 := []rune(b)
+	s1, s2 := buf.runes(a, b)
+
+	dist, events := buf.distance(s1, s2, diff)
+	var d1, d2 string
+	if diff {
+		d1, d2 = buf.diffs(s1, s2, events)
+	}
+	return dist, d1, d2
+}
+
+// ComputeDistanceStats computes the levenshtein distance between the two
+// strings the same way as ComputeDistance with the diff flag set to true.
+// It also returns statistics of edit operations found during traceback.
+func ComputeDistanceStats(a, b string) (int, string, string, Stats) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	return buf.ComputeDistanceStats(a, b)
+}
+
+// ComputeDistanceStats works like the ComputeDistanceStats function,
+// reusing memory of the buffer. Only tagged strings are allocated.
+func (buf *Buffer) ComputeDistanceStats(
+	a, b string,
+) (int, string, string, Stats) {
+	s1, s2 := buf.runes(a, b)
+
+	var dist int
+	var events []eventType
+	switch {
+	case a == b:
+		events = appendEvent(buf.events[:0], same, len(s1))
+	case len(s1) == 0:
+		dist = len(s2)
+		events = appendEvent(buf.events[:0], del, len(s2))
+	case len(s2) == 0:
+		dist = len(s1)
+		events = appendEvent(buf.events[:0], ins, len(s1))
+	default:
+		dist, events = buf.distance(s1, s2, true)
+	}
+	buf.events = events
+	d1, d2 := buf.diffs(s1, s2, events)
+	return dist, d1, d2, runeStats(s1, s2, events)
+}
 
+// distance calculates edit distance between two non-empty different
+// strings. If diff is true, it also returns edit events in reverse order.
+// Common prefix and suffix are excluded from calculations, but their
+// events are added to the result.
+func (buf *Buffer) distance(s1, s2 []rune, diff bool) (int, []eventType) {
+	s1, s2, pre, suf := trimAffixes(s1, s2)
+	var events []eventType
+	if diff {
+		events = appendEvent(buf.events[:0], same, suf)
+	}
+
+	var dist int
+	switch {
+	case len(s1) == 0:
+		dist = len(s2)
+		if diff {
+			events = appendEvent(events, del, len(s2))
+		}
+	case len(s2) == 0:
+		dist = len(s1)
+		if diff {
+			events = appendEvent(events, ins, len(s1))
+		}
+	default:
+		dist, events = buf.matrixDistance(s1, s2, diff, events)
+	}
+	if !diff {
+		return dist, nil
+	}
+
+	events = appendEvent(events, same, pre)
+	buf.events = events
+	return dist, events
+}
+
+// matrixDistance calculates edit distance between two non-empty strings
+// using the full DP matrix if diff is true. Edit events are appended to
+// the events slice.
+func (buf *Buffer) matrixDistance(
+	s1, s2 []rune,
+	diff bool,
+	events []eventType,
+) (int, []eventType) {
 	lenS1 := len(s1)
 	lenS2 := len(s2)
 
@@ -149,12 +285,13 @@ func ComputeDistance(a, b string, diff bool) (int, string, string) {
 	var m []uint8
 
 	if diff {
-		m = make([]uint8, 0, cl*rl)
+		m = buf.newMatrix(cl * rl)
 	}
 
 	// init the row
-	x := make([]uint8, lenS1+1)
+	x := buf.newRow(lenS1 + 1)
 	// we start from 1 because index 0 is already 0.
+	x[0] = 0
 	for i := 1; i < len(x); i++ {
 		x[i] = uint8(i)
 	}
@@ -187,11 +324,10 @@ func ComputeDistance(a, b string, diff bool) (int, string, string) {
 			m = append(m, x...)
 		}
 	}
-	var d1, d2 string
 	if diff {
-		d1, d2 = traceBack(s1, s2, m)
+		events = traceBack(s1, s2, m, events)
 	}
-	return int(x[lenS1]), d1, d2
+	return int(x[lenS1]), events
 }
 
 // ComputeDistanceTerm comutes edit distance between two strings and
@@ -219,6 +355,7 @@ func termColors(s string) string {
 		red    = "\033[1;31m"
 		green  = "\033[1;30;42m"
 		yellow = "\033[1;30;43m"
+		cyan   = "\033[1;30;46m"
 		end    = "\033[0m"
 	)
 	s = strings.ReplaceAll(s, "<ins>", green)
@@ -227,6 +364,8 @@ func termColors(s string) string {
 	s = strings.ReplaceAll(s, "</del>", end)
 	s = strings.ReplaceAll(s, "<subst>", yellow)
 	s = strings.ReplaceAll(s, "</subst>", end)
+	s = strings.ReplaceAll(s, "<case>", cyan)
+	s = strings.ReplaceAll(s, "</case>", end)
 	return s
 }
 
@@ -237,14 +376,15 @@ func min(a, b uint8) uint8 {
 	return a
 }
 
-func traceBack(s1, s2 []rune, m []uint8) (string, string) {
+// traceBack appends edit events in reverse order from the matrix to
+// the events slice.
+func traceBack(s1, s2 []rune, m []uint8, events []eventType) []eventType {
 	var e eventType
 	var dist, prevDist int
 	var iDel, jDel, iIns, jIns, iSubst, jSubst int
 	lenS1 := len(s1)
 	lenS2 := len(s2)
 	rl := lenS1 + 1
-	events := make([]eventType, 0, lenS1+lenS2)
 	i := lenS2
 	j := lenS1
 	prevDist = int(m[rl*i+j])
@@ -282,65 +422,85 @@ func traceBack(s1, s2 []rune, m []uint8) (string, string) {
 		prevDist = dist
 		events = append(events, e)
 	}
-	return diffs(s1, s2, events)
+	return events
 }
 
+// diffs converts edit events to tagged strings.
 func diffs(s1, s2 []rune, events []eventType) (string, string) {
-	var prev, event eventType
-	var deletes, inserts int
-	lenS1 := len(s1)
-	lenS2 := len(s2)
-	d1 := make([]rune, 0, (lenS1+lenS2)*2)
-	d2 := make([]rune, 0, (lenS1+lenS2)*2)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	return buf.diffs(s1, s2, events)
+}
+
+// diffs converts edit events to tagged strings. Both strings are built
+// in the memory of the buffer, and only the results are allocated.
+func (buf *Buffer) diffs(s1, s2 []rune, events []eventType) (string, string) {
+	if len(events) == 0 {
+		return "", ""
+	}
+	tags := appendTags(buf.tags[:0], s1, s2, events, false)
+	n := len(tags)
+	tags = appendTags(tags, s1, s2, events, true)
+	buf.tags = tags
+	return string(tags[:n]), string(tags[n:])
+}
 
+// appendTags appends the tagged first string, or the tagged second string
+// if second is true, to dst.
+func appendTags(
+	dst []byte,
+	s1, s2 []rune,
+	events []eventType,
+	second bool,
+) []byte {
+	var prev eventType
+	var deletes, inserts int
 	i := 0
 	for j := len(events) - 1; j >= 0; j-- {
-		event = events[j]
-		// init prev event
-		if prev == none {
-			if event != same {
-				d1 = append(d1, []rune("<"+event.String()+">")...)
-				d2 = append(d2, []rune("<"+invert(event).String()+">")...)
-			}
-		} else if event != prev {
-			if prev != same {
-				d1 = append(d1, []rune("</"+prev.String()+">")...)
-				d2 = append(d2, []rune("</"+invert(prev).String()+">")...)
+		event := events[j]
+		if event != prev {
+			if prev != none && prev != same {
+				dst = appendTag(dst, prev, true, second)
 			}
 			if event != same {
-
-				d1 = append(d1, []rune("<"+event.String()+">")...)
-
-				d2 = append(d2, []rune("<"+invert(event).String()+">")...)
+				dst = appendTag(dst, event, false, second)
 			}
 		}
 		switch event {
 		case del:
-			c1 := s2[i-inserts]
-			c2 := s2[i-inserts]
-			d1 = append(d1, c1)
-			d2 = append(d2, c2)
+			dst = utf8.AppendRune(dst, s2[i-inserts])
 			deletes++
 		case ins:
-			c1 := s1[i-deletes]
-			c2 := s1[i-deletes]
-			d1 = append(d1, c1)
-			d2 = append(d2, c2)
+			dst = utf8.AppendRune(dst, s1[i-deletes])
 			inserts++
 		default:
-			c1 := s1[i-deletes]
-			c2 := s2[i-inserts]
-			d1 = append(d1, c1)
-			d2 = append(d2, c2)
+			if second {
+				dst = utf8.AppendRune(dst, s2[i-inserts])
+			} else {
+				dst = utf8.AppendRune(dst, s1[i-deletes])
+			}
 		}
 		prev = event
 		i++
 	}
-	if event != same {
-		d1 = append(d1, []rune("</"+event.String()+">")...)
-		d2 = append(d2, []rune("</"+invert(event).String()+">")...)
+	if prev != same {
+		dst = appendTag(dst, prev, true, second)
+	}
+	return dst
+}
+
+// appendTag appends an opening or a closing tag of an event. Tags of the
+// second string show inverted events.
+func appendTag(dst []byte, e eventType, closing, second bool) []byte {
+	if second {
+		e = invert(e)
+	}
+	dst = append(dst, '<')
+	if closing {
+		dst = append(dst, '/')
 	}
-	return string(d1), string(d2)
+	dst = append(dst, e.String()...)
+	return append(dst, '>')
 }
 
 func invert(e eventType) eventType {
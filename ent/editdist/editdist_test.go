@@ -50,6 +50,11 @@ func TestMax(t *testing.T) {
 		assert.Equal(t, v.dist, dist, msg)
 		assert.Equal(t, v.abort, ab, msg)
 	}
+
+	// only the last row exceeds max, the result is still aborted.
+	dist, ab := editdist.ComputeDistanceMax("ab", "ba", 1)
+	assert.Equal(t, 1, dist)
+	assert.True(t, ab)
 }
 
 func TestDiff(t *testing.T) {
@@ -0,0 +1,333 @@
+package editdist
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind uint8
+
+const (
+	literal tokenKind = iota
+	anyRune
+	class
+)
+
+// runeRange is an inclusive range of runes in a character class.
+type runeRange struct {
+	lo, hi rune
+}
+
+// token is a unit of a pattern that matches exactly one rune.
+type token struct {
+	kind tokenKind
+	// src is the text of the token in the pattern, it is used for tags.
+	src    string
+	r      rune
+	ranges []runeRange
+	negate bool
+}
+
+func (t token) match(r rune) bool {
+	switch t.kind {
+	case anyRune:
+		return true
+	case class:
+		for _, v := range t.ranges {
+			if r >= v.lo && r <= v.hi {
+				return !t.negate
+			}
+		}
+		return t.negate
+	default:
+		return t.r == r
+	}
+}
+
+// Pattern is a compiled query string that can contain wildcards. Every
+// token of a pattern matches exactly one rune at zero cost:
+//
+//   - `?` matches any rune;
+//   - `[ck]` matches any rune of the set, ranges like `[a-z]` are allowed,
+//     `[^ck]` matches any rune that is not in the set;
+//   - `\` escapes the next rune, so `\?`, `\[`, `\]` and `\\` match `?`,
+//     `[`, `]` and `\` literally, also inside of brackets;
+//   - `]` right after `[` or `[^` is a member of the set;
+//   - any other rune matches itself.
+//
+// An unterminated `[` and a trailing `\` are treated as literal runes.
+type Pattern struct {
+	tokens []token
+}
+
+// CompilePattern converts a pattern string into a Pattern. It never fails,
+// malformed constructs are treated as literals.
+func CompilePattern(p string) Pattern {
+	var res Pattern
+	rs := []rune(p)
+	for i := 0; i < len(rs); i++ {
+		switch rs[i] {
+		case '?':
+			res.tokens = append(res.tokens, token{kind: anyRune, src: "?"})
+		case '\\':
+			if i+1 < len(rs) {
+				i++
+				res.tokens = append(res.tokens,
+					token{src: string(rs[i-1 : i+1]), r: rs[i]})
+			} else {
+				res.tokens = append(res.tokens, token{src: `\`, r: '\\'})
+			}
+		case '[':
+			tk, end, ok := parseClass(rs, i)
+			if ok {
+				res.tokens = append(res.tokens, tk)
+				i = end
+			} else {
+				res.tokens = append(res.tokens, token{src: "[", r: '['})
+			}
+		default:
+			res.tokens = append(res.tokens, token{src: string(rs[i]), r: rs[i]})
+		}
+	}
+	return res
+}
+
+// parseClass parses a character class that starts at the position i. It
+// returns the token, the position of the closing bracket and true if the
+// class is terminated.
+func parseClass(rs []rune, i int) (token, int, bool) {
+	tk := token{kind: class}
+	start := i
+	i++
+	if i < len(rs) && rs[i] == '^' {
+		tk.negate = true
+		i++
+	}
+	first := true
+	for ; i < len(rs); i++ {
+		r := rs[i]
+		if r == ']' && !first {
+			tk.src = string(rs[start : i+1])
+			return tk, i, true
+		}
+		first = false
+		if r == '\\' && i+1 < len(rs) {
+			i++
+			r = rs[i]
+		}
+		rng := runeRange{lo: r, hi: r}
+		if i+2 < len(rs) && rs[i+1] == '-' && rs[i+2] != ']' {
+			i += 2
+			hi := rs[i]
+			if hi == '\\' && i+1 < len(rs) {
+				i++
+				hi = rs[i]
+			}
+			rng.hi = hi
+		}
+		tk.ranges = append(tk.ranges, rng)
+	}
+	return tk, 0, false
+}
+
+// Len returns the number of tokens in the pattern.
+func (p Pattern) Len() int {
+	return len(p.tokens)
+}
+
+// ComputeDistancePattern computes edit distance between a pattern (see
+// Pattern for its syntax) and a string. Wildcards of the pattern match
+// runes of the string at zero cost. If diff is true, it also returns
+// tagged strings, where the first string shows tokens of the pattern.
+func ComputeDistancePattern(pattern, b string, diff bool) (int, string, string) {
+	p := CompilePattern(pattern)
+	s2 := []rune(b)
+	dist, events := p.distance(s2, diff)
+	if !diff {
+		return dist, "", ""
+	}
+	d1, d2 := p.diffs(s2, events)
+	return dist, d1, d2
+}
+
+// ComputeDistancePatternStats computes edit distance the same way as
+// ComputeDistancePattern with the diff flag set to true. It also returns
+// statistics of edit operations found during traceback.
+func ComputeDistancePatternStats(pattern, b string) (int, string, string, Stats) {
+	p := CompilePattern(pattern)
+	s2 := []rune(b)
+	dist, events := p.distance(s2, true)
+	d1, d2 := p.diffs(s2, events)
+	st := newStats(events, len(p.tokens), len(s2), func(i, j int) bool {
+		return p.tokens[i].match(s2[j])
+	})
+	return dist, d1, d2, st
+}
+
+// distance calculates edit distance between the pattern and a string. If
+// diff is true, it also returns edit events in reverse order.
+func (p Pattern) distance(s2 []rune, diff bool) (int, []eventType) {
+	lenP := len(p.tokens)
+	lenS2 := len(s2)
+	rl := lenP + 1
+
+	var m []int
+	if diff {
+		m = make([]int, 0, rl*(lenS2+1))
+	}
+
+	x := make([]int, rl)
+	for i := 1; i < rl; i++ {
+		x[i] = i
+	}
+	if diff {
+		m = append(m, x...)
+	}
+
+	for i := 1; i <= lenS2; i++ {
+		prev := i
+		for j := 1; j <= lenP; j++ {
+			current := x[j-1] // match
+			if !p.tokens[j-1].match(s2[i-1]) {
+				current = minInt(
+					x[j-1]+1, // substitution
+					minInt(prev+1, // insertion
+						x[j]+1), // deletion
+				)
+			}
+			x[j-1] = prev
+			prev = current
+		}
+		x[lenP] = prev
+		if diff {
+			m = append(m, x...)
+		}
+	}
+
+	if !diff {
+		return x[lenP], nil
+	}
+	return x[lenP], p.traceBack(s2, m)
+}
+
+// ComputeDistancePatternMax computes edit distance between a pattern and
+// a string the same way as ComputeDistancePattern. It aborts
+// calculations when the edit distance exceeds max value, in such case the
+// returned boolean is true.
+func ComputeDistancePatternMax(pattern, b string, max int) (int, bool) {
+	p := CompilePattern(pattern)
+	if len(b) == 0 || len(p.tokens) == 0 {
+		dist := len(p.tokens) + utf8.RuneCountInString(b)
+		if max > 0 && dist > max {
+			return max, true
+		}
+		return dist, false
+	}
+
+	lenP := len(p.tokens)
+	x := make([]int, lenP+1)
+	for i := 1; i < len(x); i++ {
+		x[i] = i
+	}
+
+	i := 0
+	for _, r := range b {
+		i++
+		prev := i
+		rowDist := prev
+		for j := 1; j <= lenP; j++ {
+			current := x[j-1] // match
+			if !p.tokens[j-1].match(r) {
+				current = minInt(
+					x[j-1]+1, // substitution
+					minInt(prev+1, // insertion
+						x[j]+1), // deletion
+				)
+			}
+			if current < rowDist {
+				rowDist = current
+			}
+			x[j-1] = prev
+			prev = current
+		}
+		if max > 0 && rowDist > max {
+			return max, true
+		}
+		x[lenP] = prev
+	}
+	if max > 0 && x[lenP] > max {
+		return max, true
+	}
+	return x[lenP], false
+}
+
+// traceBack collects edit events in reverse order from the matrix of
+// a pattern calculation.
+func (p Pattern) traceBack(s2 []rune, m []int) []eventType {
+	rl := len(p.tokens) + 1
+	events := make([]eventType, 0, len(p.tokens)+len(s2))
+	i, j := len(s2), len(p.tokens)
+	for i > 0 || j > 0 {
+		dist := m[rl*i+j]
+		// gaps are preferred, so they are pushed to the ends of strings.
+		switch {
+		case i > 0 && m[rl*(i-1)+j]+1 == dist:
+			// rune of the string does not exist in the pattern.
+			events = append(events, del)
+			i--
+		case j > 0 && m[rl*i+j-1]+1 == dist:
+			// token of the pattern does not exist in the string.
+			events = append(events, ins)
+			j--
+		case p.tokens[j-1].match(s2[i-1]) && m[rl*(i-1)+j-1] == dist:
+			events = append(events, same)
+			i, j = i-1, j-1
+		default:
+			events = append(events, subst)
+			i, j = i-1, j-1
+		}
+	}
+	return events
+}
+
+// diffs converts edit events to tagged strings, where the first string
+// shows tokens of the pattern.
+func (p Pattern) diffs(s2 []rune, events []eventType) (string, string) {
+	var d1, d2 strings.Builder
+	var prev eventType
+	var ip, is int
+	for k := len(events) - 1; k >= 0; k-- {
+		e := events[k]
+		if e != prev {
+			if prev != none && prev != same {
+				d1.WriteString("</" + prev.String() + ">")
+				d2.WriteString("</" + invert(prev).String() + ">")
+			}
+			if e != same {
+				d1.WriteString("<" + e.String() + ">")
+				d2.WriteString("<" + invert(e).String() + ">")
+			}
+		}
+		switch e {
+		case ins:
+			d1.WriteString(p.tokens[ip].src)
+			d2.WriteString(p.tokens[ip].src)
+			ip++
+		case del:
+			d1.WriteRune(s2[is])
+			d2.WriteRune(s2[is])
+			is++
+		default:
+			d1.WriteString(p.tokens[ip].src)
+			d2.WriteRune(s2[is])
+			ip++
+			is++
+		}
+		prev = e
+	}
+	if prev != none && prev != same {
+		d1.WriteString("</" + prev.String() + ">")
+		d2.WriteString("</" + invert(prev).String() + ">")
+	}
+	return d1.String(), d2.String()
+}
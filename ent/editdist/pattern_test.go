@@ -0,0 +1,71 @@
+package editdist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPattern(t *testing.T) {
+	testData := []struct {
+		pattern, str string
+		dist         int
+		d1, d2       string
+	}{
+		{"Amblyseius hib?sci", "Amblyseius hibisci", 0,
+			"Amblyseius hib?sci", "Amblyseius hibisci"},
+		{"[ck]ristata", "cristata", 0, "[ck]ristata", "cristata"},
+		{"[ck]ristata", "kristata", 0, "[ck]ristata", "kristata"},
+		{"[ck]ristata", "xristata", 1,
+			"<subst>[ck]</subst>ristata", "<subst>x</subst>ristata"},
+		{"[^ck]ristata", "cristata", 1,
+			"<subst>[^ck]</subst>ristata", "<subst>c</subst>ristata"},
+		{"[a-c]x", "bx", 0, "[a-c]x", "bx"},
+		{"[]]x", "]x", 0, "[]]x", "]x"},
+		{`a\?b`, "a?b", 0, `a\?b`, "a?b"},
+		{`a\?b`, "axb", 1, `a<subst>\?</subst>b`, "a<subst>x</subst>b"},
+		{`a\[b]`, "a[b]", 0, `a\[b]`, "a[b]"},
+		{"[abc", "[abc", 0, "[abc", "[abc"},
+		{"Pomatomus", "Pomatomus saltator", 9,
+			"Pomatomus<del> saltator</del>", "Pomatomus<ins> saltator</ins>"},
+		{"Poma tomus", "Pomatomos", 2,
+			"Poma<ins> </ins>tom<subst>u</subst>s",
+			"Poma<del> </del>tom<subst>o</subst>s"},
+		{"?", "", 1, "<ins>?</ins>", "<del>?</del>"},
+		{"", "ab", 2, "<del>ab</del>", "<ins>ab</ins>"},
+		{"", "", 0, "", ""},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.pattern, v.str)
+		dist, d1, d2 := editdist.ComputeDistancePattern(v.pattern, v.str, true)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.d1, d1, msg)
+		assert.Equal(t, v.d2, d2, msg)
+		dist, _, _ = editdist.ComputeDistancePattern(v.pattern, v.str, false)
+		assert.Equal(t, v.dist, dist, msg)
+	}
+}
+
+func TestPatternMax(t *testing.T) {
+	testData := []struct {
+		pattern, str string
+		dist         int
+		abort        bool
+	}{
+		{"Amblyseius hib?sci", "Amblyseius hibisci", 0, false},
+		{"[ck]rist?ta", "xristata", 1, false},
+		{"Pomatomus", "Pomatomus saltator", 2, true},
+		{"?", "", 1, false},
+		{"", "abc", 2, true},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.pattern, v.str)
+		dist, ab := editdist.ComputeDistancePatternMax(v.pattern, v.str, 2)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, v.abort, ab, msg)
+	}
+}
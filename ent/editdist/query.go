@@ -0,0 +1,145 @@
+package editdist
+
+import "unicode/utf8"
+
+// maxBitParallel is the longest query that fits into one machine word
+// for bit-parallel calculations.
+const maxBitParallel = 64
+
+// Query keeps a preprocessed string for comparing it with many other
+// strings. Runes of the query are converted only once, and for queries
+// that are not longer than 64 runes bit-masks are precomputed for Myers'
+// bit-parallel algorithm, that calculates edit distance in O(n) time for
+// a string of n runes. Query is immutable and can be used concurrently.
+type Query struct {
+	str   string
+	runes []rune
+
+	// bitParallel is true if the query can use Myers' algorithm.
+	bitParallel bool
+
+	// ascii keeps bit-masks of positions of ASCII runes in the query.
+	ascii [128]uint64
+
+	// peq keeps bit-masks of positions of non-ASCII runes in the query.
+	peq map[rune]uint64
+
+	// last is the mask of the last position of the query.
+	last uint64
+}
+
+// NewQuery preprocesses a query string.
+func NewQuery(s string) *Query {
+	rs := []rune(s)
+	q := &Query{
+		str:         s,
+		runes:       rs,
+		bitParallel: len(rs) > 0 && len(rs) <= maxBitParallel,
+	}
+	if !q.bitParallel {
+		return q
+	}
+
+	for i, r := range rs {
+		bit := uint64(1) << uint(i)
+		if r >= 0 && r < 128 {
+			q.ascii[r] |= bit
+			continue
+		}
+		if q.peq == nil {
+			q.peq = make(map[rune]uint64)
+		}
+		q.peq[r] |= bit
+	}
+	q.last = uint64(1) << uint(len(rs)-1)
+	return q
+}
+
+// String returns the original query string.
+func (q *Query) String() string {
+	return q.str
+}
+
+// Distance calculates edit distance between the query and a string. For
+// distances up to 255 the result is the same as the one of ComputeDistance.
+// ComputeDistance keeps rows of the matrix in uint8, so it is not exact
+// for larger distances. Queries of up to 64 runes use Myers' algorithm and
+// stay exact, longer queries fall back to ComputeDistance and share its
+// limit.
+func (q *Query) Distance(b string) int {
+	if !q.bitParallel {
+		if len(q.runes) == 0 {
+			return utf8.RuneCountInString(b)
+		}
+		dist, _, _ := ComputeDistance(q.str, b, false)
+		return dist
+	}
+	dist, _ := q.myers(b, 0, 0)
+	return dist
+}
+
+// DistanceMax calculates edit distance between the query and a string,
+// aborting calculations when the distance exceeds max. For distances up
+// to 255 the results are the same as the ones of ComputeDistanceMax, the
+// limit is described in Distance.
+func (q *Query) DistanceMax(b string, max int) (int, bool) {
+	if !q.bitParallel || max <= 0 {
+		if max <= 0 {
+			return q.Distance(b), false
+		}
+		return ComputeDistanceMax(q.str, b, max)
+	}
+
+	n := utf8.RuneCountInString(b)
+	lenDiff := n - len(q.runes)
+	if lenDiff < 0 {
+		lenDiff = -lenDiff
+	}
+	if lenDiff > max {
+		return max, true
+	}
+	return q.myers(b, max, n)
+}
+
+// myers calculates edit distance using Myers' bit-parallel algorithm in
+// Hyyrö's formulation. If max is positive, n has to be the number of runes
+// in b, and the calculation stops as soon as the distance cannot become
+// smaller than max.
+func (q *Query) myers(b string, max, n int) (int, bool) {
+	pv := ^uint64(0)
+	var mv uint64
+	score := len(q.runes)
+	var i int
+	for _, r := range b {
+		i++
+		var eq uint64
+		if r >= 0 && r < 128 {
+			eq = q.ascii[r]
+		} else if q.peq != nil {
+			eq = q.peq[r]
+		}
+
+		xv := eq | mv
+		xh := (((eq & pv) + pv) ^ pv) | eq
+		ph := mv | ^(xh | pv)
+		mh := pv & xh
+		if ph&q.last != 0 {
+			score++
+		} else if mh&q.last != 0 {
+			score--
+		}
+		ph = (ph << 1) | 1
+		mh <<= 1
+		pv = mh | ^(xv | ph)
+		mv = ph & xv
+
+		// every remaining rune can decrease the score by one at most.
+		if max > 0 && score-(n-i) > max {
+			return max, true
+		}
+	}
+	if max > 0 && score > max {
+		return max, true
+	}
+	return score, false
+}
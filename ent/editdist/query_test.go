@@ -0,0 +1,61 @@
+package editdist_test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	testData := []struct {
+		query, str string
+		dist       int
+	}{
+		{"Hello", "He1lo", 1},
+		{"Pomatomus", "Pomщtomus", 1},
+		{"Pomщtomus", "Pomatomus", 1},
+		{"sitting", "kitten", 3},
+		{"Boston", "Chicago", 7},
+		{"", "test", 4},
+		{"test", "", 4},
+		{"", "", 0},
+		{strings.Repeat("ab", 40), strings.Repeat("ab", 39) + "b", 1},
+		{strings.Repeat("ab", 40), strings.Repeat("ba", 40), 2},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.query, v.str)
+		q := editdist.NewQuery(v.query)
+		assert.Equal(t, v.dist, q.Distance(v.str), msg)
+	}
+
+	// Myers' algorithm stays exact above the uint8 limit of ComputeDistance.
+	q := editdist.NewQuery("a")
+	assert.Equal(t, 300, q.Distance(strings.Repeat("b", 300)))
+}
+
+func TestQueryFile(t *testing.T) {
+	f, err := os.Open("../../testdata/fuzzy.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.Nil(t, err)
+
+	for _, row := range rows {
+		msg := fmt.Sprintf("'%s' vs '%s'", row[0], row[1])
+		q := editdist.NewQuery(row[0])
+		dist, _, _ := editdist.ComputeDistance(row[0], row[1], false)
+		assert.Equal(t, dist, q.Distance(row[1]), msg)
+		for _, max := range []int{1, 2, 3} {
+			dist, ab := editdist.ComputeDistanceMax(row[0], row[1], max)
+			qDist, qAb := q.DistanceMax(row[1], max)
+			assert.Equal(t, dist, qDist, msg)
+			assert.Equal(t, ab, qAb, msg)
+		}
+	}
+}
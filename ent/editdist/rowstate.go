@@ -0,0 +1,121 @@
+package editdist
+
+// RowState keeps rows of Levenshtein calculations for a query string,
+// while runes of another string are fed to it one at a time. It is a
+// building block for walking prefix trees (tries, DAWGs), where every
+// node pushes one row with Step and backtracking removes it with
+// Rollback.
+type RowState struct {
+	query []rune
+
+	// width is the number of cells in every row (len(query)+1).
+	width int
+
+	// rows keeps all rows in one flat slice: the initial row and one row
+	// per rune given to Step.
+	rows []int
+
+	// mins keeps the minimal value of every row.
+	mins []int
+}
+
+// NewRowState creates a RowState for a query string. The state starts
+// at depth 0, where the distance is the number of runes in the query.
+func NewRowState(query string) *RowState {
+	q := []rune(query)
+	w := len(q) + 1
+	rows := make([]int, w, w*(len(q)+8))
+	for i := range rows {
+		rows[i] = i
+	}
+	return &RowState{
+		query: q,
+		width: w,
+		rows:  rows,
+		mins:  []int{0},
+	}
+}
+
+// Step adds a rune to the compared string and calculates the next row.
+func (rs *RowState) Step(r rune) {
+	w := rs.width
+	depth := len(rs.mins)
+	rs.rows = append(rs.rows, make([]int, w)...)
+	prev := rs.rows[len(rs.rows)-2*w : len(rs.rows)-w]
+	cur := rs.rows[len(rs.rows)-w:]
+
+	cur[0] = depth
+	rowMin := depth
+	for j := 1; j < w; j++ {
+		current := prev[j-1] // match
+		if rs.query[j-1] != r {
+			current = minInt(
+				minInt(prev[j-1]+1, // substitution
+					cur[j-1]+1), // insertion
+				prev[j]+1) // deletion
+		}
+		cur[j] = current
+		if current < rowMin {
+			rowMin = current
+		}
+	}
+	rs.mins = append(rs.mins, rowMin)
+}
+
+// Rollback removes the last n steps. If n is larger than the current
+// depth, the state returns to its initial row. Zero or negative n does
+// nothing.
+func (rs *RowState) Rollback(n int) {
+	if n <= 0 {
+		return
+	}
+	if n > rs.Depth() {
+		n = rs.Depth()
+	}
+	rs.rows = rs.rows[:len(rs.rows)-n*rs.width]
+	rs.mins = rs.mins[:len(rs.mins)-n]
+}
+
+// Depth returns the number of runes given to Step and not rolled back.
+func (rs *RowState) Depth() int {
+	return len(rs.mins) - 1
+}
+
+// RowMin returns the minimal value of the current row. No continuation
+// of the current string can have edit distance smaller than RowMin.
+func (rs *RowState) RowMin() int {
+	return rs.mins[len(rs.mins)-1]
+}
+
+// CanMatch returns false if no continuation of the current string can be
+// within max edit distance from the query. In such case the whole
+// subtree of a prefix tree can be pruned.
+func (rs *RowState) CanMatch(max int) bool {
+	return rs.RowMin() <= max
+}
+
+// Distance returns edit distance between the query and the string
+// given to the state so far.
+func (rs *RowState) Distance() int {
+	return rs.rows[len(rs.rows)-1]
+}
+
+// Clone returns an independent copy of the state.
+func (rs *RowState) Clone() *RowState {
+	res := &RowState{
+		query: rs.query,
+		width: rs.width,
+		rows:  make([]int, len(rs.rows), cap(rs.rows)),
+		mins:  make([]int, len(rs.mins), cap(rs.mins)),
+	}
+	copy(res.rows, rs.rows)
+	copy(res.mins, rs.mins)
+	return res
+}
+
+func minInt(a, b int) int {
+	if b < a {
+		return b
+	}
+	return a
+}
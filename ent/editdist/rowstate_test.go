@@ -0,0 +1,79 @@
+package editdist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRowState(t *testing.T) {
+	testData := []struct {
+		query, str string
+		dist       int
+	}{
+		{"Hello", "He1lo", 1},
+		{"Pomatomus", "Pom-tomus", 1},
+		{"Pomatomus", "Pomщtomus", 1},
+		{"sitting", "kitten", 3},
+		{"Boston", "Chicago", 7},
+		{"", "test", 4},
+		{"test", "", 4},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.query, v.str)
+		rs := editdist.NewRowState(v.query)
+		for _, r := range v.str {
+			rs.Step(r)
+		}
+		assert.Equal(t, v.dist, rs.Distance(), msg)
+		assert.LessOrEqual(t, rs.RowMin(), rs.Distance(), msg)
+	}
+}
+
+func TestRowStateRollback(t *testing.T) {
+	rs := editdist.NewRowState("Pomatomus")
+	for _, r := range "Pomax" {
+		rs.Step(r)
+	}
+	assert.Equal(t, 5, rs.Depth())
+	assert.Equal(t, 1, rs.RowMin())
+	assert.False(t, rs.CanMatch(0))
+
+	rs.Rollback(0)
+	rs.Rollback(-3)
+	assert.Equal(t, 5, rs.Depth())
+	assert.Equal(t, 1, rs.RowMin())
+
+	rs.Rollback(1)
+	assert.Equal(t, 4, rs.Depth())
+	assert.Equal(t, 0, rs.RowMin())
+	assert.True(t, rs.CanMatch(0))
+
+	for _, r := range "tomus" {
+		rs.Step(r)
+	}
+	assert.Equal(t, 0, rs.Distance())
+
+	rs.Rollback(100)
+	assert.Equal(t, 0, rs.Depth())
+	assert.Equal(t, 9, rs.Distance())
+}
+
+func TestRowStateClone(t *testing.T) {
+	rs := editdist.NewRowState("Pomatomus")
+	for _, r := range "Poma" {
+		rs.Step(r)
+	}
+	cl := rs.Clone()
+	for _, r := range "tomus" {
+		rs.Step(r)
+	}
+	for _, r := range "tomos" {
+		cl.Step(r)
+	}
+	assert.Equal(t, 0, rs.Distance())
+	assert.Equal(t, 1, cl.Distance())
+}
@@ -0,0 +1,132 @@
+package editdist
+
+import "unicode/utf8"
+
+// Stats contains counts of edit operations and other details of
+// differences between two strings. Counts of edit operations are found
+// during traceback, so they are filled only when diffs are calculated.
+// CommonPrefix, CommonSuffix and FirstDiff need no traceback, they can be
+// calculated separately by ComputeAffixes.
+type Stats struct {
+	// Substitutions is the number of substituted runes, substitutions
+	// that change only case are included.
+	Substitutions int
+	// Insertions is the number of runes that exist only in the first
+	// string. They are marked by "ins" tags in the first diff string.
+	Insertions int
+	// Deletions is the number of runes that exist only in the second
+	// string. They are marked by "del" tags in the first diff string.
+	Deletions int
+	// CommonPrefix is the number of identical runes at the start of
+	// both strings.
+	CommonPrefix int
+	// CommonSuffix is the number of identical runes at the end of both
+	// strings. It does not overlap with CommonPrefix.
+	CommonSuffix int
+	// FirstDiff is the position of the first edit event counting from 1.
+	// It is 0 if the strings are identical.
+	FirstDiff int
+}
+
+// ComputeAffixes finds CommonPrefix, CommonSuffix and FirstDiff of two
+// strings. Counts of edit operations stay empty. It does not allocate,
+// so it is cheap enough to run for every comparison.
+func ComputeAffixes(a, b string) Stats {
+	var res Stats
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		r1, n1 := utf8.DecodeRuneInString(a[i:])
+		r2, n2 := utf8.DecodeRuneInString(b[j:])
+		if r1 != r2 {
+			break
+		}
+		res.CommonPrefix++
+		i, j = i+n1, j+n2
+	}
+	if i == len(a) && j == len(b) {
+		return res
+	}
+	res.FirstDiff = res.CommonPrefix + 1
+
+	a, b = a[i:], b[j:]
+	l := minInt(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	for res.CommonSuffix < l {
+		r1, n1 := utf8.DecodeLastRuneInString(a)
+		r2, n2 := utf8.DecodeLastRuneInString(b)
+		if r1 != r2 {
+			break
+		}
+		res.CommonSuffix++
+		a, b = a[:len(a)-n1], b[:len(b)-n2]
+	}
+	return res
+}
+
+// ComputeAffixesPattern works like ComputeAffixes, where the first
+// string is a pattern. Look at Pattern for the syntax.
+func ComputeAffixesPattern(pattern, b string) Stats {
+	p := CompilePattern(pattern)
+	s2 := []rune(b)
+	return affixes(len(p.tokens), len(s2), func(i, j int) bool {
+		return p.tokens[i].match(s2[j])
+	})
+}
+
+// newStats counts edit operations from edit events and finds common
+// prefix and suffix of two strings. The eq function compares i-th rune of
+// the first string with j-th rune of the second one.
+func newStats(
+	events []eventType,
+	len1, len2 int,
+	eq func(i, j int) bool,
+) Stats {
+	res := affixes(len1, len2, eq)
+	for _, e := range events {
+		switch e {
+		case subst, caseChange:
+			res.Substitutions++
+		case ins:
+			// rune of the first string does not exist in the second one.
+			res.Insertions++
+		case del:
+			// rune of the second string does not exist in the first one.
+			res.Deletions++
+		}
+	}
+	return res
+}
+
+// affixes finds common prefix and suffix of two sequences, and the
+// position of their first difference.
+func affixes(len1, len2 int, eq func(i, j int) bool) Stats {
+	var res Stats
+	l := minInt(len1, len2)
+	for res.CommonPrefix < l && eq(res.CommonPrefix, res.CommonPrefix) {
+		res.CommonPrefix++
+	}
+	if res.CommonPrefix == len1 && len1 == len2 {
+		return res
+	}
+	res.FirstDiff = res.CommonPrefix + 1
+	l -= res.CommonPrefix
+	for res.CommonSuffix < l &&
+		eq(len1-res.CommonSuffix-1, len2-res.CommonSuffix-1) {
+		res.CommonSuffix++
+	}
+	return res
+}
+
+// runeStats calculates statistics for two rune slices.
+func runeStats(s1, s2 []rune, events []eventType) Stats {
+	return newStats(events, len(s1), len(s2), func(i, j int) bool {
+		return s1[i] == s2[j]
+	})
+}
+
+// appendEvent appends n copies of an event to the events slice.
+func appendEvent(events []eventType, e eventType, n int) []eventType {
+	for i := 0; i < n; i++ {
+		events = append(events, e)
+	}
+	return events
+}
@@ -0,0 +1,79 @@
+package editdist_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	testData := []struct {
+		str1, str2 string
+		dist       int
+		stats      editdist.Stats
+	}{
+		{"Hello", "He1lo", 1, editdist.Stats{
+			Substitutions: 1, CommonPrefix: 2, CommonSuffix: 2, FirstDiff: 3}},
+		{"Pomatomus", "Poma  tomus", 2, editdist.Stats{
+			Deletions: 2, CommonPrefix: 4, CommonSuffix: 5, FirstDiff: 5}},
+		{"Poma  tomus", "Pomatomus", 2, editdist.Stats{
+			Insertions: 2, CommonPrefix: 4, CommonSuffix: 5, FirstDiff: 5}},
+		{"rebase", "basic", 4, editdist.Stats{
+			Substitutions: 1, Insertions: 2, Deletions: 1, FirstDiff: 1}},
+		{"aaa", "aa", 1, editdist.Stats{
+			Insertions: 1, CommonPrefix: 2, FirstDiff: 3}},
+		{"test1", "", 5, editdist.Stats{Insertions: 5, FirstDiff: 1}},
+		{"", "test2", 5, editdist.Stats{Deletions: 5, FirstDiff: 1}},
+		{"Щука", "щука", 1, editdist.Stats{
+			Substitutions: 1, CommonSuffix: 3, FirstDiff: 1}},
+		{"Hello", "Hello", 0, editdist.Stats{CommonPrefix: 5}},
+		{"", "", 0, editdist.Stats{}},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		dist, d1, d2, st := editdist.ComputeDistanceStats(v.str1, v.str2)
+		dist0, d10, d20 := editdist.ComputeDistance(v.str1, v.str2, true)
+		assert.Equal(t, v.dist, dist, msg)
+		assert.Equal(t, dist0, dist, msg)
+		assert.Equal(t, d10, d1, msg)
+		assert.Equal(t, d20, d2, msg)
+		assert.Equal(t, v.stats, st, msg)
+
+		aff := editdist.ComputeAffixes(v.str1, v.str2)
+		assert.Equal(t, editdist.Stats{
+			CommonPrefix: v.stats.CommonPrefix,
+			CommonSuffix: v.stats.CommonSuffix,
+			FirstDiff:    v.stats.FirstDiff,
+		}, aff, msg)
+	}
+}
+
+// TestStatsTags checks that counts of insertions and deletions follow
+// the tags of the first diff string.
+func TestStatsTags(t *testing.T) {
+	_, d1, d2, st := editdist.ComputeDistanceStats("Poma tomus", "Pomatomos")
+	assert.Equal(t, "Poma<ins> </ins>tom<subst>u</subst>s", d1)
+	assert.Equal(t, "Poma<del> </del>tom<subst>o</subst>s", d2)
+	assert.Equal(t, editdist.Stats{Substitutions: 1, Insertions: 1,
+		CommonPrefix: 4, CommonSuffix: 1, FirstDiff: 5}, st)
+
+	_, d1, _, st = editdist.ComputeDistanceStats("Pomatomus", "Poma  tomus")
+	assert.Equal(t, "Poma<del>  </del>tomus", d1)
+	assert.Equal(t, 2, st.Deletions)
+	assert.Equal(t, 0, st.Insertions)
+}
+
+func TestStatsCasePattern(t *testing.T) {
+	_, _, _, _, st := editdist.ComputeDistanceCaseStats("aablyseius", "Amblyseius", 0.25)
+	assert.Equal(t, editdist.Stats{
+		Substitutions: 2, CommonSuffix: 8, FirstDiff: 1}, st)
+
+	_, _, _, st = editdist.ComputeDistancePatternStats("[ck]ristat?", "kristatus")
+	assert.Equal(t, editdist.Stats{
+		Deletions: 1, CommonPrefix: 8, FirstDiff: 9}, st)
+	assert.Equal(t, editdist.Stats{CommonPrefix: 8, FirstDiff: 9},
+		editdist.ComputeAffixesPattern("[ck]ristat?", "kristatus"))
+}
@@ -0,0 +1,147 @@
+package index
+
+import "github.com/gnames/levenshtein/ent/editdist"
+
+// BKTree is a Burkhard-Keller tree, a metric tree that uses edit distance
+// between strings. It allows to find all strings within a radius of a
+// query without scanning the whole dictionary. BKTree is not safe for
+// concurrent inserts, but it can be queried concurrently.
+type BKTree struct {
+	root *bkNode
+	size int
+}
+
+type bkNode struct {
+	term     string
+	children map[int]*bkNode
+	// maxEdge is the largest distance between the node and its children.
+	maxEdge int
+}
+
+// NewBKTree creates an empty BKTree.
+func NewBKTree() *BKTree {
+	return &BKTree{}
+}
+
+// Len returns the number of strings in the tree.
+func (t *BKTree) Len() int {
+	return t.size
+}
+
+// Insert adds a string to the tree. It returns false if the string is
+// already in the tree.
+func (t *BKTree) Insert(term string) bool {
+	if t.root == nil {
+		t.root = &bkNode{term: term}
+		t.size++
+		return true
+	}
+
+	node := t.root
+	for {
+		d, _, _ := editdist.ComputeDistance(term, node.term, false)
+		if d == 0 && term == node.term {
+			return false
+		}
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{term: term}
+			node.maxEdge = max(node.maxEdge, d)
+			t.size++
+			return true
+		}
+		node = child
+	}
+}
+
+// Build adds many strings to the tree.
+func (t *BKTree) Build(terms []string) {
+	for _, v := range terms {
+		t.Insert(v)
+	}
+}
+
+// Search returns all strings that are within the radius from the query.
+// Matches are sorted by edit distance and then alphabetically.
+func (t *BKTree) Search(query string, radius int) []Match {
+	var res []Match
+	if t.root == nil || radius < 0 {
+		return res
+	}
+
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		d, ok := node.distance(query, radius)
+		if !ok {
+			continue
+		}
+		if d <= radius {
+			res = append(res, Match{Term: node.term, EditDist: d})
+		}
+		for k, v := range node.children {
+			if k >= d-radius && k <= d+radius {
+				stack = append(stack, v)
+			}
+		}
+	}
+	sortMatches(res)
+	return res
+}
+
+// Nearest returns k strings that are the closest to the query. Matches
+// are sorted by edit distance and then alphabetically.
+func (t *BKTree) Nearest(query string, k int) []Match {
+	if t.root == nil || k <= 0 {
+		return nil
+	}
+
+	h := make(matchHeap, 0, k)
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		// radius shrinks as better matches are found.
+		radius := h.radius(k)
+		var d int
+		if radius < 0 {
+			d, _, _ = editdist.ComputeDistance(query, node.term, false)
+		} else {
+			var ok bool
+			if d, ok = node.distance(query, radius); !ok {
+				continue
+			}
+		}
+		h.add(Match{Term: node.term, EditDist: d}, k)
+
+		radius = h.radius(k)
+		for key, v := range node.children {
+			if radius < 0 || (key >= d-radius && key <= d+radius) {
+				stack = append(stack, v)
+			}
+		}
+	}
+	res := []Match(h)
+	sortMatches(res)
+	return res
+}
+
+// distance calculates edit distance between the query and the node. It
+// returns false if neither the node, nor any of its children can be
+// within the radius. The cutoff of the calculation is set to the radius
+// plus the largest distance to a child, so the exact distance is known
+// whenever children have to be checked.
+func (n *bkNode) distance(query string, radius int) (int, bool) {
+	cutoff := radius + n.maxEdge
+	if cutoff == 0 {
+		d, _, _ := editdist.ComputeDistance(query, n.term, false)
+		return d, true
+	}
+	d, aborted := editdist.ComputeDistanceMax(query, n.term, cutoff)
+	return d, !aborted
+}
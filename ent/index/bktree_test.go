@@ -0,0 +1,37 @@
+package index_test
+
+import (
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBKTree(t *testing.T) {
+	dict := dictionary(t)
+	tree := index.NewBKTree()
+	tree.Build(dict)
+	assert.Equal(t, len(dict), tree.Len())
+	assert.False(t, tree.Insert(dict[0]))
+
+	for _, q := range queries {
+		for _, r := range []int{0, 1, 2, 3} {
+			assert.Equal(t, bruteForce(dict, q, r), tree.Search(q, r), q)
+		}
+	}
+}
+
+func TestBKTreeNearest(t *testing.T) {
+	dict := dictionary(t)
+	tree := index.NewBKTree()
+	tree.Build(dict)
+
+	for _, q := range queries {
+		all := bruteForce(dict, q, 1000)
+		for _, k := range []int{1, 5, 20} {
+			assert.Equal(t, all[:k], tree.Nearest(q, k), q)
+		}
+	}
+	assert.Nil(t, tree.Nearest("Puma", 0))
+	assert.Nil(t, index.NewBKTree().Nearest("Puma", 3))
+}
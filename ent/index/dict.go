@@ -0,0 +1,337 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+)
+
+// DictVersion is the version of the binary format of Dict files. Files
+// of other versions are rejected and have to be rebuilt.
+const DictVersion uint32 = 1
+
+// dictMagic marks the beginning of a Dict file.
+var dictMagic = [8]byte{'L', 'E', 'V', 'D', 'I', 'C', 'T', 0}
+
+var (
+	// ErrDictFormat means that the data is not a Dict file.
+	ErrDictFormat = errors.New("not a fuzzy dictionary file")
+	// ErrDictVersion means that the Dict file has an unsupported version.
+	ErrDictVersion = errors.New("unsupported fuzzy dictionary version")
+	// ErrDictChecksum means that the Dict file is corrupted.
+	ErrDictChecksum = errors.New("fuzzy dictionary checksum mismatch")
+)
+
+// Dict is a precomputed dictionary, where strings are deduplicated and
+// bucketed by their length in runes. A lookup compares a query only with
+// strings of suitable lengths. Dict can be saved to a binary file and
+// loaded back without preparing the strings again. Dict is immutable and
+// can be queried concurrently.
+type Dict struct {
+	// lens are sorted lengths of buckets.
+	lens []int
+	// buckets keep sorted strings of the same length in runes.
+	buckets map[int][]string
+	size    int
+}
+
+// NewDict creates a dictionary from a list of strings.
+func NewDict(terms []string) *Dict {
+	d := &Dict{buckets: make(map[int][]string)}
+	seen := make(map[string]struct{}, len(terms))
+	for _, v := range terms {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		l := utf8.RuneCountInString(v)
+		d.buckets[l] = append(d.buckets[l], v)
+	}
+	for l, v := range d.buckets {
+		sort.Strings(v)
+		d.lens = append(d.lens, l)
+		d.size += len(v)
+	}
+	sort.Ints(d.lens)
+	return d
+}
+
+// Len returns the number of strings in the dictionary.
+func (d *Dict) Len() int {
+	return d.size
+}
+
+// Search returns all strings of the dictionary within edit distance k
+// from the query. Matches are sorted by edit distance and then
+// alphabetically.
+func (d *Dict) Search(query string, k int) []Match {
+	var res []Match
+	if k < 0 {
+		return res
+	}
+	l := utf8.RuneCountInString(query)
+	for tl := max(l-k, 0); tl <= l+k; tl++ {
+		for _, v := range d.buckets[tl] {
+			if k == 0 {
+				if v == query {
+					res = append(res, Match{Term: v})
+				}
+				continue
+			}
+			if dist, aborted := editdist.ComputeDistanceMax(query, v, k); !aborted {
+				res = append(res, Match{Term: v, EditDist: dist})
+			}
+		}
+	}
+	sortMatches(res)
+	return res
+}
+
+// dictHeaderLen is the size of the header of a Dict file: a magic string,
+// a version, the number of buckets and the size of the body in bytes.
+const dictHeaderLen = 24
+
+// WriteTo writes the dictionary in a binary format. The format starts
+// with a magic string, a version, the number of buckets and the size of
+// the body. The body keeps buckets of strings, and is followed by its
+// CRC32 checksum.
+func (d *Dict) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	var header [dictHeaderLen]byte
+	copy(header[:8], dictMagic[:])
+	binary.LittleEndian.PutUint32(header[8:12], DictVersion)
+	binary.LittleEndian.PutUint32(header[12:16], uint32(len(d.lens)))
+	binary.LittleEndian.PutUint64(header[16:24], d.bodyLen())
+	if _, err := bw.Write(header[:]); err != nil {
+		return cw.n, err
+	}
+
+	crc := crc32.NewIEEE()
+	body := io.MultiWriter(bw, crc)
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) error {
+		n := binary.PutUvarint(buf, v)
+		_, err := body.Write(buf[:n])
+		return err
+	}
+
+	for _, l := range d.lens {
+		bucket := d.buckets[l]
+		if err := putUvarint(uint64(l)); err != nil {
+			return cw.n, err
+		}
+		if err := putUvarint(uint64(len(bucket))); err != nil {
+			return cw.n, err
+		}
+		for _, v := range bucket {
+			if err := putUvarint(uint64(len(v))); err != nil {
+				return cw.n, err
+			}
+			if _, err := io.WriteString(body, v); err != nil {
+				return cw.n, err
+			}
+		}
+	}
+
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc.Sum32())
+	if _, err := bw.Write(sum[:]); err != nil {
+		return cw.n, err
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// bodyLen returns the size of the body written by WriteTo.
+func (d *Dict) bodyLen() uint64 {
+	var res int
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, l := range d.lens {
+		bucket := d.buckets[l]
+		res += binary.PutUvarint(buf, uint64(l))
+		res += binary.PutUvarint(buf, uint64(len(bucket)))
+		for _, v := range bucket {
+			res += binary.PutUvarint(buf, uint64(len(v))) + len(v)
+		}
+	}
+	return uint64(res)
+}
+
+// ReadDict reads a dictionary written by WriteTo. It returns
+// ErrDictFormat, ErrDictVersion or ErrDictChecksum if the data cannot be
+// used. The body is read into memory and its checksum is verified before
+// the dictionary is built.
+func ReadDict(r io.Reader) (*Dict, error) {
+	var header [dictHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDictFormat, err)
+	}
+	bucketsNum, bodyLen, err := parseDictHeader(header[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// the buffer grows with the data that is actually read, so a broken
+	// size in the header cannot cause a huge allocation.
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(r, int64(bodyLen)+4))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDictFormat, err)
+	}
+	if uint64(n) != bodyLen+4 {
+		return nil, fmt.Errorf("%w: %w", ErrDictFormat, io.ErrUnexpectedEOF)
+	}
+	return parseDictBody(buf.Bytes(), bucketsNum)
+}
+
+// parseDictHeader checks the header of a Dict file, and returns the
+// number of buckets and the size of the body.
+func parseDictHeader(header []byte) (uint32, uint64, error) {
+	if [8]byte(header[:8]) != dictMagic {
+		return 0, 0, ErrDictFormat
+	}
+	if v := binary.LittleEndian.Uint32(header[8:12]); v != DictVersion {
+		return 0, 0, fmt.Errorf("%w: %d", ErrDictVersion, v)
+	}
+	bucketsNum := binary.LittleEndian.Uint32(header[12:16])
+	bodyLen := binary.LittleEndian.Uint64(header[16:24])
+	if bodyLen > math.MaxInt32*16 {
+		return 0, 0, fmt.Errorf("%w: body of %d bytes", ErrDictFormat, bodyLen)
+	}
+	return bucketsNum, bodyLen, nil
+}
+
+// parseDictBody builds a dictionary from the body of a Dict file followed
+// by its checksum. The checksum is verified first, and then every size
+// read from the body is checked against the bytes that are left, so
+// broken data returns ErrDictFormat instead of huge allocations. Strings
+// are copied, so the data can be released afterwards.
+func parseDictBody(data []byte, bucketsNum uint32) (*Dict, error) {
+	body, sum := data[:len(data)-4], data[len(data)-4:]
+	if binary.LittleEndian.Uint32(sum) != crc32.ChecksumIEEE(body) {
+		return nil, ErrDictChecksum
+	}
+
+	// every bucket takes at least two bytes.
+	if uint64(bucketsNum) > uint64(len(body)/2) {
+		return nil, fmt.Errorf("%w: %d buckets in %d bytes",
+			ErrDictFormat, bucketsNum, len(body))
+	}
+	uvarint := func() (uint64, error) {
+		v, n := binary.Uvarint(body)
+		if n <= 0 {
+			return 0, fmt.Errorf("%w: broken varint", ErrDictFormat)
+		}
+		body = body[n:]
+		return v, nil
+	}
+
+	d := &Dict{
+		lens:    make([]int, 0, bucketsNum),
+		buckets: make(map[int][]string, bucketsNum),
+	}
+	for i := uint32(0); i < bucketsNum; i++ {
+		l, err := uvarint()
+		if err != nil {
+			return nil, err
+		}
+		// lengths are unique and sorted, and a string of l runes takes
+		// l bytes at least.
+		if l > uint64(len(body)) ||
+			(len(d.lens) > 0 && int(l) <= d.lens[len(d.lens)-1]) {
+			return nil, fmt.Errorf("%w: bucket of length %d", ErrDictFormat, l)
+		}
+		num, err := uvarint()
+		if err != nil {
+			return nil, err
+		}
+		// every string takes at least one byte for its size.
+		if num > uint64(len(body)) {
+			return nil, fmt.Errorf("%w: %d strings in %d bytes",
+				ErrDictFormat, num, len(body))
+		}
+		bucket := make([]string, 0, num)
+		for j := uint64(0); j < num; j++ {
+			size, err := uvarint()
+			if err != nil {
+				return nil, err
+			}
+			if size > uint64(len(body)) {
+				return nil, fmt.Errorf("%w: string of %d bytes in %d bytes",
+					ErrDictFormat, size, len(body))
+			}
+			bucket = append(bucket, string(body[:size]))
+			body = body[size:]
+		}
+		d.lens = append(d.lens, int(l))
+		d.buckets[int(l)] = bucket
+		d.size += len(bucket)
+	}
+	if len(body) > 0 {
+		return nil, fmt.Errorf("%w: %d bytes after the last bucket",
+			ErrDictFormat, len(body))
+	}
+	return d, nil
+}
+
+// Save writes the dictionary to a file.
+func (d *Dict) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = d.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadDict reads a dictionary from a file created by Save. The file is
+// read into memory at once, and the strings are copied from it into the
+// dictionary, so the file data is released after loading. Unlike
+// ReadDict, LoadDict rejects files with extra data after the dictionary.
+func LoadDict(path string) (*Dict, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < dictHeaderLen+4 {
+		return nil, fmt.Errorf("%w: file of %d bytes", ErrDictFormat, len(data))
+	}
+
+	bucketsNum, bodyLen, err := parseDictHeader(data[:dictHeaderLen])
+	if err != nil {
+		return nil, err
+	}
+	data = data[dictHeaderLen:]
+	if uint64(len(data)) != bodyLen+4 {
+		return nil, fmt.Errorf("%w: body of %d bytes, expected %d",
+			ErrDictFormat, len(data)-4, bodyLen)
+	}
+	return parseDictBody(data, bucketsNum)
+}
+
+// countWriter counts written bytes.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
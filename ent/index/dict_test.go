@@ -0,0 +1,159 @@
+package index_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDict(t *testing.T) {
+	dict := dictionary(t)
+	d := index.NewDict(append(dict, dict[:10]...))
+	assert.Equal(t, len(dict), d.Len())
+
+	for _, q := range queries {
+		for _, k := range []int{0, 1, 2} {
+			assert.Equal(t, bruteForce(dict, q, k), d.Search(q, k), q)
+		}
+	}
+}
+
+func TestDictSaveLoad(t *testing.T) {
+	dict := dictionary(t)
+	d := index.NewDict(dict)
+	path := filepath.Join(t.TempDir(), "dict.bin")
+	assert.Nil(t, d.Save(path))
+
+	d2, err := index.LoadDict(path)
+	assert.Nil(t, err)
+	assert.Equal(t, d, d2)
+	for _, q := range queries {
+		assert.Equal(t, d.Search(q, 2), d2.Search(q, 2), q)
+	}
+
+	empty := index.NewDict(nil)
+	assert.Nil(t, empty.Save(path))
+	d2, err = index.LoadDict(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, d2.Len())
+
+	_, err = index.LoadDict(filepath.Join(t.TempDir(), "nofile"))
+	assert.NotNil(t, err)
+}
+
+func TestDictErrors(t *testing.T) {
+	d := index.NewDict([]string{"Pomatomus", "Puma", "щука", ""})
+	var buf bytes.Buffer
+	n, err := d.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	data := buf.Bytes()
+
+	d2, err := index.ReadDict(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, d, d2)
+
+	_, err = index.ReadDict(bytes.NewReader([]byte("Pomatomus saltator")))
+	assert.ErrorIs(t, err, index.ErrDictFormat)
+
+	_, err = index.ReadDict(bytes.NewReader(data[:20]))
+	assert.ErrorIs(t, err, index.ErrDictFormat)
+
+	stale := bytes.Clone(data)
+	binary.LittleEndian.PutUint32(stale[8:12], index.DictVersion+1)
+	_, err = index.ReadDict(bytes.NewReader(stale))
+	assert.ErrorIs(t, err, index.ErrDictVersion)
+
+	corrupt := bytes.Clone(data)
+	corrupt[len(corrupt)-6] ^= 0xFF
+	_, err = index.ReadDict(bytes.NewReader(corrupt))
+	assert.ErrorIs(t, err, index.ErrDictChecksum)
+}
+
+// dictData builds Dict file data from a body with the correct size and
+// checksum, so broken bodies pass the checksum and reach the parser.
+func dictData(buckets uint32, body []byte) []byte {
+	res := []byte("LEVDICT\x00")
+	res = binary.LittleEndian.AppendUint32(res, index.DictVersion)
+	res = binary.LittleEndian.AppendUint32(res, buckets)
+	res = binary.LittleEndian.AppendUint64(res, uint64(len(body)))
+	res = append(res, body...)
+	return binary.LittleEndian.AppendUint32(res, crc32.ChecksumIEEE(body))
+}
+
+func TestDictBroken(t *testing.T) {
+	huge := binary.AppendUvarint(nil, 1<<62)
+	testData := []struct {
+		msg     string
+		buckets uint32
+		body    []byte
+	}{
+		{"too many buckets", 1 << 31, []byte{4, 1, 4, 'P', 'u', 'm', 'a'}},
+		{"huge length", 1, append(huge, 1, 4, 'P', 'u', 'm', 'a')},
+		{"huge number of strings", 1,
+			append(append([]byte{4}, huge...), 4, 'P', 'u', 'm', 'a')},
+		{"huge string", 1, append([]byte{4, 1}, huge...)},
+		{"broken varint", 1, bytes.Repeat([]byte{0xff}, 11)},
+		{"missing strings", 1, []byte{4, 2, 4, 'P', 'u', 'm', 'a'}},
+		{"extra bytes", 1, []byte{4, 1, 4, 'P', 'u', 'm', 'a', 0}},
+		{"unsorted lengths", 2, []byte{4, 1, 4, 'P', 'u', 'm', 'a',
+			2, 1, 2, 'P', 'u'}},
+	}
+	for _, v := range testData {
+		_, err := index.ReadDict(bytes.NewReader(dictData(v.buckets, v.body)))
+		assert.ErrorIs(t, err, index.ErrDictFormat, v.msg)
+	}
+
+	d, err := index.ReadDict(bytes.NewReader(
+		dictData(1, []byte{4, 1, 4, 'P', 'u', 'm', 'a'}),
+	))
+	assert.Nil(t, err)
+	assert.Equal(t, index.NewDict([]string{"Puma"}), d)
+
+	// a huge body size in the header does not cause a huge allocation.
+	data := dictData(1, []byte{4, 1, 4, 'P', 'u', 'm', 'a'})
+	binary.LittleEndian.PutUint64(data[16:24], 1<<62)
+	_, err = index.ReadDict(bytes.NewReader(data))
+	assert.ErrorIs(t, err, index.ErrDictFormat)
+	binary.LittleEndian.PutUint64(data[16:24], 1<<30)
+	_, err = index.ReadDict(bytes.NewReader(data))
+	assert.ErrorIs(t, err, index.ErrDictFormat)
+}
+
+// TestDictTruncated checks that every truncated file is rejected by both
+// the reader and the file loader.
+func TestDictTruncated(t *testing.T) {
+	d := index.NewDict([]string{"Pomatomus", "Puma", "щука", ""})
+	var buf bytes.Buffer
+	_, err := d.WriteTo(&buf)
+	assert.Nil(t, err)
+	data := buf.Bytes()
+
+	path := filepath.Join(t.TempDir(), "dict.bin")
+	for i := 0; i < len(data); i++ {
+		_, err = index.ReadDict(bytes.NewReader(data[:i]))
+		assert.ErrorIs(t, err, index.ErrDictFormat, i)
+
+		assert.Nil(t, os.WriteFile(path, data[:i], 0644))
+		_, err = index.LoadDict(path)
+		assert.ErrorIs(t, err, index.ErrDictFormat, i)
+	}
+
+	// extra data after the dictionary is not read from a stream, but a
+	// file has to contain only the dictionary.
+	r := bytes.NewReader(append(bytes.Clone(data), 'x'))
+	d2, err := index.ReadDict(r)
+	assert.Nil(t, err)
+	assert.Equal(t, d, d2)
+	assert.Equal(t, 1, r.Len())
+
+	assert.Nil(t, os.WriteFile(path, append(bytes.Clone(data), 'x'), 0644))
+	_, err = index.LoadDict(path)
+	assert.ErrorIs(t, err, index.ErrDictFormat)
+}
@@ -0,0 +1,73 @@
+// Package index contains data structures for fast fuzzy lookups of
+// strings in large dictionaries. All of them calculate edit distance
+// using the editdist package, so found distances are the same as the ones
+// returned by Levenshtein's Compare method.
+package index
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Match is a dictionary string found by a fuzzy query.
+type Match struct {
+	// Term is the found dictionary string.
+	Term string
+	// EditDist is the edit distance between the query and the Term.
+	EditDist int
+	// Tags1 is the query with tagged differences. It is set only by
+	// lookups that support diffs, when they are requested.
+	Tags1 string
+	// Tags2 is the Term with tagged differences. It is set only by
+	// lookups that support diffs, when they are requested.
+	Tags2 string
+}
+
+// less sorts matches by edit distance, and then alphabetically.
+func (m Match) less(o Match) bool {
+	if m.EditDist != o.EditDist {
+		return m.EditDist < o.EditDist
+	}
+	return m.Term < o.Term
+}
+
+func sortMatches(ms []Match) {
+	sort.Slice(ms, func(i, j int) bool { return ms[i].less(ms[j]) })
+}
+
+// matchHeap is a max-heap that keeps the worst of k nearest matches on
+// top.
+type matchHeap []Match
+
+func (h matchHeap) Len() int           { return len(h) }
+func (h matchHeap) Less(i, j int) bool { return h[j].less(h[i]) }
+func (h matchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x any)        { *h = append(*h, x.(Match)) }
+func (h *matchHeap) Pop() any {
+	old := *h
+	n := len(old)
+	res := old[n-1]
+	*h = old[:n-1]
+	return res
+}
+
+// add adds a match to the heap that keeps no more than k matches.
+func (h *matchHeap) add(m Match, k int) {
+	if h.Len() < k {
+		heap.Push(h, m)
+		return
+	}
+	if m.less((*h)[0]) {
+		(*h)[0] = m
+		heap.Fix(h, 0)
+	}
+}
+
+// radius returns the largest edit distance that can still get into the
+// heap, or -1 if there is no limit yet.
+func (h matchHeap) radius(k int) int {
+	if len(h) < k {
+		return -1
+	}
+	return h[0].EditDist
+}
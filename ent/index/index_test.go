@@ -0,0 +1,60 @@
+package index_test
+
+import (
+	"encoding/csv"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/stretchr/testify/assert"
+)
+
+var queries = []string{
+	"Aablyseius hibisci",
+	"Pomatomus saltator",
+	"Asimina parviflora",
+	"Aphanes microcarpa",
+	"Puma",
+	"",
+}
+
+// dictionary returns unique strings from the test data.
+func dictionary(t *testing.T) []string {
+	f, err := os.Open("../../testdata/fuzzy.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.Nil(t, err)
+
+	seen := make(map[string]struct{})
+	var res []string
+	for _, row := range rows {
+		for _, v := range row[:2] {
+			if _, ok := seen[v]; !ok {
+				seen[v] = struct{}{}
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// bruteForce finds matches by comparing the query with every string.
+func bruteForce(dict []string, query string, k int) []index.Match {
+	var res []index.Match
+	for _, v := range dict {
+		d, _, _ := editdist.ComputeDistance(query, v, false)
+		if d <= k {
+			res = append(res, index.Match{Term: v, EditDist: d})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].EditDist != res[j].EditDist {
+			return res[i].EditDist < res[j].EditDist
+		}
+		return res[i].Term < res[j].Term
+	})
+	return res
+}
@@ -0,0 +1,212 @@
+package index
+
+import (
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+)
+
+// MutableDict is a dictionary for fuzzy lookups that can be changed while
+// it is queried. Strings are bucketed by their length in runes, like in
+// Dict. Removed strings are only marked as removed and skipped by lookups
+// until Compact purges them, so a daily diff of names can be applied
+// without rebuilding the dictionary. All methods are safe for concurrent
+// use.
+type MutableDict struct {
+	mu      sync.RWMutex
+	buckets map[int][]string
+	// live keeps strings that are in the dictionary.
+	live map[string]struct{}
+	// removed keeps strings that are removed, but not purged from buckets.
+	removed map[string]struct{}
+}
+
+// NewMutableDict creates a dictionary from a list of strings.
+func NewMutableDict(terms []string) *MutableDict {
+	d := &MutableDict{
+		buckets: make(map[int][]string),
+		live:    make(map[string]struct{}, len(terms)),
+		removed: make(map[string]struct{}),
+	}
+	for _, v := range terms {
+		d.add(v)
+	}
+	return d
+}
+
+// Len returns the number of strings in the dictionary.
+func (d *MutableDict) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.live)
+}
+
+// Add adds a string to the dictionary. It returns false if the string is
+// already there.
+func (d *MutableDict) Add(term string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.add(term)
+}
+
+// Remove removes a string from the dictionary. It returns false if there
+// was no such string.
+func (d *MutableDict) Remove(term string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.remove(term)
+}
+
+// Replace replaces one string with another in one step, so readers see
+// either the old string or the new one, never neither or both. It returns
+// false and changes nothing if the old string is not in the dictionary,
+// or if the new string is already there. Replacing a string with itself
+// changes nothing and returns true.
+func (d *MutableDict) Replace(oldTerm, newTerm string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.live[oldTerm]; !ok {
+		return false
+	}
+	if oldTerm == newTerm {
+		return true
+	}
+	if _, ok := d.live[newTerm]; ok {
+		return false
+	}
+	d.remove(oldTerm)
+	d.add(newTerm)
+	return true
+}
+
+// Apply removes and adds strings in one step, readers see the dictionary
+// either before or after all the changes.
+func (d *MutableDict) Apply(add, remove []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, v := range remove {
+		d.remove(v)
+	}
+	for _, v := range add {
+		d.add(v)
+	}
+}
+
+// Compact purges removed strings from the buckets and returns their
+// number. Buckets without removed strings are kept as they are.
+func (d *MutableDict) Compact() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res := len(d.removed)
+	if res == 0 {
+		return res
+	}
+	for l, bucket := range d.buckets {
+		var kept []string
+		for i, v := range bucket {
+			if _, ok := d.removed[v]; ok {
+				if kept == nil {
+					kept = make([]string, i, len(bucket))
+					copy(kept, bucket[:i])
+				}
+				continue
+			}
+			if kept != nil {
+				kept = append(kept, v)
+			}
+		}
+		switch {
+		case kept == nil:
+		case len(kept) == 0:
+			delete(d.buckets, l)
+		default:
+			d.buckets[l] = kept
+		}
+	}
+	d.removed = make(map[string]struct{})
+	return res
+}
+
+// Search returns all strings of the dictionary within edit distance k
+// from the query. If diff is true, matches contain tagged differences.
+// Matches are sorted by edit distance and then alphabetically.
+//
+// The read lock is held during the whole scan, so a search sees the
+// dictionary either before or after every Add, Remove, Replace or Apply.
+// Writers wait until running searches are finished.
+func (d *MutableDict) Search(query string, k int, diff bool) []Match {
+	var res []Match
+	if k < 0 {
+		return res
+	}
+	l := utf8.RuneCountInString(query)
+
+	d.mu.RLock()
+	for tl := max(l-k, 0); tl <= l+k; tl++ {
+		for _, v := range d.buckets[tl] {
+			// removed strings stay in buckets until Compact.
+			if _, ok := d.live[v]; !ok {
+				continue
+			}
+			if k == 0 {
+				if v == query {
+					res = append(res, Match{Term: v})
+				}
+				continue
+			}
+			if dist, aborted := editdist.ComputeDistanceMax(query, v, k); !aborted {
+				res = append(res, Match{Term: v, EditDist: dist})
+			}
+		}
+	}
+	d.mu.RUnlock()
+
+	if diff {
+		for i := range res {
+			_, res[i].Tags1, res[i].Tags2 = editdist.ComputeDistance(
+				query, res[i].Term, true,
+			)
+		}
+	}
+	sortMatches(res)
+	return res
+}
+
+// Snapshot returns an immutable Dict with the current strings, for
+// example to save it to a file.
+func (d *MutableDict) Snapshot() *Dict {
+	d.mu.RLock()
+	terms := make([]string, 0, len(d.live))
+	for v := range d.live {
+		terms = append(terms, v)
+	}
+	d.mu.RUnlock()
+	sort.Strings(terms)
+	return NewDict(terms)
+}
+
+func (d *MutableDict) add(term string) bool {
+	if _, ok := d.live[term]; ok {
+		return false
+	}
+	d.live[term] = struct{}{}
+	// the string is still in its bucket.
+	if _, ok := d.removed[term]; ok {
+		delete(d.removed, term)
+		return true
+	}
+	l := utf8.RuneCountInString(term)
+	d.buckets[l] = append(d.buckets[l], term)
+	return true
+}
+
+func (d *MutableDict) remove(term string) bool {
+	if _, ok := d.live[term]; !ok {
+		return false
+	}
+	delete(d.live, term)
+	d.removed[term] = struct{}{}
+	return true
+}
@@ -0,0 +1,132 @@
+package index_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutableDict(t *testing.T) {
+	d := index.NewMutableDict([]string{"Pomatomus", "Puma", "Pomatomus"})
+	assert.Equal(t, 2, d.Len())
+	assert.False(t, d.Add("Puma"))
+	assert.True(t, d.Add("Pomatomas"))
+
+	res := d.Search("Pomatomus", 1, true)
+	assert.Equal(t, []index.Match{
+		{Term: "Pomatomus", Tags1: "Pomatomus", Tags2: "Pomatomus"},
+		{Term: "Pomatomas", EditDist: 1,
+			Tags1: "Pomatom<subst>u</subst>s", Tags2: "Pomatom<subst>a</subst>s"},
+	}, res)
+
+	assert.True(t, d.Remove("Pomatomus"))
+	assert.False(t, d.Remove("Pomatomus"))
+	assert.Equal(t, []index.Match{
+		{Term: "Pomatomas", EditDist: 1},
+	}, d.Search("Pomatomus", 1, false))
+
+	assert.False(t, d.Replace("Boston", "Chicago"))
+	// the new string is already there, nothing changes.
+	assert.False(t, d.Replace("Puma", "Pomatomas"))
+	assert.Equal(t, 2, d.Len())
+	assert.Equal(t, 1, len(d.Search("Puma", 0, false)))
+	assert.True(t, d.Replace("Puma", "Puma"))
+	assert.Equal(t, 2, d.Len())
+	assert.True(t, d.Replace("Puma", "Pumo"))
+	assert.Equal(t, []index.Match{
+		{Term: "Pumo", EditDist: 1},
+	}, d.Search("Puma", 1, false))
+
+	// a removed string comes back before compaction.
+	assert.True(t, d.Add("Pomatomus"))
+	assert.Equal(t, 2, len(d.Search("Pomatomus", 1, false)))
+
+	d.Apply([]string{"Bomatomus"}, []string{"Pomatomas", "Pumo"})
+	assert.Equal(t, 2, d.Len())
+	assert.Equal(t, 3, d.Compact())
+	assert.Equal(t, 0, d.Compact())
+	assert.Equal(t, []index.Match{
+		{Term: "Pomatomus"},
+		{Term: "Bomatomus", EditDist: 1},
+	}, d.Search("Pomatomus", 2, false))
+	assert.Equal(t, 2, d.Snapshot().Len())
+}
+
+func TestMutableDictConcurrent(t *testing.T) {
+	dict := dictionary(t)[:2000]
+	d := index.NewMutableDict(dict[:1000])
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = d.Search(dict[i*50+j], 2, false)
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, v := range dict[1000:] {
+			d.Replace(dict[i], v)
+			if i%100 == 0 {
+				d.Compact()
+			}
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, 1000, d.Len())
+	for _, v := range dict[1000:1010] {
+		res := d.Search(v, 0, false)
+		assert.Equal(t, 1, len(res), fmt.Sprintf("'%s'", v))
+	}
+}
+
+// TestMutableDictReplaceAtomic checks that a search running during
+// replacements finds either the old string or the new one.
+func TestMutableDictReplaceAtomic(t *testing.T) {
+	a, b := "Pomatomus", "Pomatomas"
+	terms := []string{a}
+	for i := 0; i < 1000; i++ {
+		terms = append(terms, fmt.Sprintf("Puma%05d", i))
+	}
+	d := index.NewMutableDict(terms)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				d.Replace(a, b)
+			} else {
+				d.Replace(b, a)
+			}
+			if i%100 == 0 {
+				d.Compact()
+			}
+		}
+	}()
+
+	for i := 0; i < 2000; i++ {
+		res := d.Search(a, 1, false)
+		if !assert.Equal(t, 1, len(res), i) {
+			break
+		}
+		assert.Contains(t, []string{a, b}, res[0].Term)
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,241 @@
+package index
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+)
+
+// qPad is used to pad strings, so their first and last runes are
+// included into the same number of q-grams as the other runes. It is not
+// a valid rune, so padding never matches runes of the input, NUL included.
+const qPad rune = -1
+
+// qPadByte replaces qPad in keys of q-grams. Keys of runes are always
+// valid UTF-8, so they never contain this byte.
+const qPadByte = 0xff
+
+// posting keeps the number of occurrences of a q-gram in a string.
+type posting struct {
+	id    int32
+	count int32
+}
+
+// Pair is a pair of dictionary strings found by a join.
+type Pair struct {
+	// Term1 is the first string of the pair.
+	Term1 string
+	// Term2 is the second string of the pair.
+	Term2 string
+	// EditDist is the edit distance between the strings.
+	EditDist int
+}
+
+// QGram is an inverted index of q-grams (substrings of q runes). It finds
+// candidates for a query using the q-gram lemma: strings within edit
+// distance k have lengths that differ by k at most (length filter), and
+// share at least max(len1, len2)+q-1-k*q padded q-grams (count filter).
+// The candidates are verified by editdist. Unlike deletion indexes, its
+// size does not grow with the edit distance, so it suits long strings.
+// QGram is not safe for concurrent inserts, but it can be queried
+// concurrently.
+type QGram struct {
+	q       int
+	terms   []string
+	termIDs map[string]int32
+	// postings keep posting lists of q-grams separately for every length
+	// of strings, so lookups read only lists of suitable lengths.
+	postings map[int]map[string][]posting
+	byLen    map[int][]int32
+}
+
+// NewQGram creates an empty index of q-grams. If q is smaller than 1,
+// it is set to 2.
+func NewQGram(q int) *QGram {
+	if q < 1 {
+		q = 2
+	}
+	return &QGram{
+		q:        q,
+		termIDs:  make(map[string]int32),
+		postings: make(map[int]map[string][]posting),
+		byLen:    make(map[int][]int32),
+	}
+}
+
+// Len returns the number of strings in the index.
+func (x *QGram) Len() int {
+	return len(x.terms)
+}
+
+// Add adds a string to the index. It returns false if the string is
+// already in the index.
+func (x *QGram) Add(term string) bool {
+	if _, ok := x.termIDs[term]; ok {
+		return false
+	}
+	id := int32(len(x.terms))
+	grams, l := x.grams(term)
+	x.terms = append(x.terms, term)
+	x.termIDs[term] = id
+	x.byLen[l] = append(x.byLen[l], id)
+	postings := x.postings[l]
+	if postings == nil {
+		postings = make(map[string][]posting)
+		x.postings[l] = postings
+	}
+	for g, c := range grams {
+		postings[g] = append(postings[g], posting{id: id, count: c})
+	}
+	return true
+}
+
+// Build adds many strings to the index.
+func (x *QGram) Build(terms []string) {
+	for _, v := range terms {
+		x.Add(v)
+	}
+}
+
+// Search returns all strings of the index that are within edit distance
+// k from the query. Matches are sorted by edit distance and then
+// alphabetically.
+func (x *QGram) Search(query string, k int) []Match {
+	var res []Match
+	for _, v := range x.search(query, k, 0) {
+		res = append(res, Match{Term: x.terms[v.id], EditDist: v.dist})
+	}
+	sortMatches(res)
+	return res
+}
+
+// Join returns all pairs of strings in the index that are within edit
+// distance k from each other (an all-pairs similarity self-join). Every
+// pair is returned once, in the order the strings were added.
+func (x *QGram) Join(k int) []Pair {
+	var res []Pair
+	for i, v := range x.terms {
+		for _, c := range x.search(v, k, i+1) {
+			res = append(res, Pair{
+				Term1: v, Term2: x.terms[c.id], EditDist: c.dist,
+			})
+		}
+	}
+	return res
+}
+
+// Term returns a string by its ID. IDs are positions of strings in the
+// order they were added to the index, duplicates excluded.
+func (x *QGram) Term(id int) string {
+	return x.terms[id]
+}
+
+// Candidates returns sorted IDs of strings that might be within edit
+// distance k from the query according to the length and count filters.
+// The candidates are not verified, so they can be verified elsewhere,
+// for example in parallel.
+func (x *QGram) Candidates(query string, k int) []int {
+	var res []int
+	if k < 0 {
+		return res
+	}
+	grams, l := x.grams(query)
+	threshold := func(tl int) int32 {
+		return int32(max(l, tl) + x.q - 1 - k*x.q)
+	}
+
+	var shared map[int32]int32
+	for tl := max(l-k, 0); tl <= l+k; tl++ {
+		t := threshold(tl)
+		// with non-positive threshold strings might share no q-grams at
+		// all, so all strings of such length are candidates.
+		if t <= 0 {
+			for _, id := range x.byLen[tl] {
+				res = append(res, int(id))
+			}
+			continue
+		}
+
+		postings := x.postings[tl]
+		if len(postings) == 0 {
+			continue
+		}
+		if shared == nil {
+			shared = make(map[int32]int32)
+		} else {
+			clear(shared)
+		}
+		for g, c := range grams {
+			for _, p := range postings[g] {
+				shared[p.id] += min(c, p.count)
+			}
+		}
+		for id, c := range shared {
+			if c >= t {
+				res = append(res, int(id))
+			}
+		}
+	}
+
+	sort.Ints(res)
+	return res
+}
+
+// found is a string of the index found by a search.
+type found struct {
+	id   int32
+	dist int
+}
+
+// search returns strings of the index within edit distance k from the
+// query, sorted by their IDs. Only candidates with IDs starting from
+// minID are verified.
+func (x *QGram) search(query string, k, minID int) []found {
+	var res []found
+	for _, id := range x.Candidates(query, k) {
+		if id < minID {
+			continue
+		}
+		term := x.terms[id]
+		if k == 0 {
+			if term == query {
+				res = append(res, found{id: int32(id)})
+			}
+			continue
+		}
+		if d, aborted := editdist.ComputeDistanceMax(query, term, k); !aborted {
+			res = append(res, found{id: int32(id), dist: d})
+		}
+	}
+	return res
+}
+
+// grams returns counts of padded q-grams of a string, and its length in
+// runes.
+func (x *QGram) grams(s string) (map[string]int32, int) {
+	rs := []rune(s)
+	padded := make([]rune, 0, len(rs)+2*(x.q-1))
+	for i := 0; i < x.q-1; i++ {
+		padded = append(padded, qPad)
+	}
+	padded = append(padded, rs...)
+	for i := 0; i < x.q-1; i++ {
+		padded = append(padded, qPad)
+	}
+
+	res := make(map[string]int32, len(padded))
+	key := make([]byte, 0, x.q*utf8.UTFMax)
+	for i := 0; i+x.q <= len(padded); i++ {
+		key = key[:0]
+		for _, r := range padded[i : i+x.q] {
+			if r == qPad {
+				key = append(key, qPadByte)
+				continue
+			}
+			key = utf8.AppendRune(key, r)
+		}
+		res[string(key)]++
+	}
+	return res, len(rs)
+}
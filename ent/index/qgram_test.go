@@ -0,0 +1,87 @@
+package index_test
+
+import (
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQGram(t *testing.T) {
+	dict := dictionary(t)
+	ks := []int{0, 1, 2, 3}
+	expected := make(map[string][][]index.Match)
+	for _, query := range queries {
+		for _, k := range ks {
+			expected[query] = append(expected[query], bruteForce(dict, query, k))
+		}
+	}
+
+	for _, q := range []int{2, 3} {
+		qg := index.NewQGram(q)
+		qg.Build(dict)
+		assert.Equal(t, len(dict), qg.Len())
+		assert.False(t, qg.Add(dict[0]))
+
+		for _, query := range queries {
+			for i, k := range ks {
+				assert.Equal(t, expected[query][i], qg.Search(query, k), query)
+			}
+		}
+	}
+}
+
+func TestQGramJoin(t *testing.T) {
+	dict := dictionary(t)[:1000]
+	qg := index.NewQGram(2)
+	qg.Build(dict)
+
+	var pairs []index.Pair
+	for i := range dict {
+		for j := i + 1; j < len(dict); j++ {
+			d, _, _ := editdist.ComputeDistance(dict[i], dict[j], false)
+			if d <= 2 {
+				pairs = append(pairs, index.Pair{
+					Term1: dict[i], Term2: dict[j], EditDist: d,
+				})
+			}
+		}
+	}
+	assert.Greater(t, len(pairs), 0)
+	assert.Equal(t, pairs, qg.Join(2))
+}
+
+func TestQGramCandidates(t *testing.T) {
+	dict := dictionary(t)[:2000]
+	qg := index.NewQGram(2)
+	qg.Build(dict)
+
+	for _, query := range queries {
+		for _, k := range []int{1, 2, 3} {
+			cands := make(map[string]bool)
+			for _, id := range qg.Candidates(query, k) {
+				cands[qg.Term(id)] = true
+			}
+			// the filters never lose a match.
+			for _, v := range bruteForce(dict, query, k) {
+				assert.True(t, cands[v.Term], query)
+			}
+			assert.Less(t, len(cands), len(dict), query)
+		}
+	}
+	assert.Equal(t, 0, len(qg.Candidates("Puma", -1)))
+}
+
+// TestQGramPad checks that padding does not match NUL runes of the input.
+func TestQGramPad(t *testing.T) {
+	qg := index.NewQGram(2)
+	qg.Build([]string{"b\x00", "\x00ab", "ab\x00"})
+	assert.Equal(t, []int{1, 2}, qg.Candidates("ab", 1))
+	assert.Equal(t, []index.Match{
+		{Term: "\x00ab", EditDist: 1}, {Term: "ab\x00", EditDist: 1},
+	}, qg.Search("ab", 1))
+	assert.Equal(t, []index.Pair{
+		{Term1: "b\x00", Term2: "ab\x00", EditDist: 1},
+	}, qg.Join(1))
+}
@@ -0,0 +1,123 @@
+package index
+
+import "github.com/gnames/levenshtein/ent/editdist"
+
+// SymSpell is a dictionary of precomputed deletions (Symmetric Delete
+// spelling correction). Every string of the dictionary is stored together
+// with all variants that can be created by deleting up to maxDist runes
+// from it. A lookup generates deletions of the query and finds candidates
+// that share a variant with it. For small distances it is orders of
+// magnitude faster than scanning the dictionary, at the price of memory.
+// Candidates are verified by editdist, so distances are exact.
+// SymSpell is not safe for concurrent inserts, but it can be queried
+// concurrently.
+type SymSpell struct {
+	maxDist int
+	terms   []string
+	termIDs map[string]int32
+	deletes map[string][]int32
+}
+
+// NewSymSpell creates an empty SymSpell dictionary that supports lookups
+// with edit distance up to maxDist.
+func NewSymSpell(maxDist int) *SymSpell {
+	return &SymSpell{
+		maxDist: max(maxDist, 0),
+		termIDs: make(map[string]int32),
+		deletes: make(map[string][]int32),
+	}
+}
+
+// MaxDist returns the largest edit distance supported by the dictionary.
+func (s *SymSpell) MaxDist() int {
+	return s.maxDist
+}
+
+// Len returns the number of strings in the dictionary.
+func (s *SymSpell) Len() int {
+	return len(s.terms)
+}
+
+// Add adds a string to the dictionary. It returns false if the string is
+// already in the dictionary.
+func (s *SymSpell) Add(term string) bool {
+	if _, ok := s.termIDs[term]; ok {
+		return false
+	}
+	id := int32(len(s.terms))
+	s.terms = append(s.terms, term)
+	s.termIDs[term] = id
+	for _, v := range deletions(term, s.maxDist) {
+		s.deletes[v] = append(s.deletes[v], id)
+	}
+	return true
+}
+
+// Build adds many strings to the dictionary.
+func (s *SymSpell) Build(terms []string) {
+	for _, v := range terms {
+		s.Add(v)
+	}
+}
+
+// Lookup returns all strings of the dictionary that are within edit
+// distance k from the query. If k is larger than the maximum distance
+// of the dictionary, the maximum distance is used instead. Matches are
+// sorted by edit distance and then alphabetically.
+func (s *SymSpell) Lookup(query string, k int) []Match {
+	var res []Match
+	if k < 0 {
+		return res
+	}
+	k = min(k, s.maxDist)
+
+	seen := make(map[int32]struct{})
+	for _, v := range deletions(query, k) {
+		for _, id := range s.deletes[v] {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			term := s.terms[id]
+			if k == 0 {
+				if term == query {
+					res = append(res, Match{Term: term})
+				}
+				continue
+			}
+			d, aborted := editdist.ComputeDistanceMax(query, term, k)
+			if !aborted {
+				res = append(res, Match{Term: term, EditDist: d})
+			}
+		}
+	}
+	sortMatches(res)
+	return res
+}
+
+// deletions returns the string itself and all unique strings that can be
+// created from it by deleting up to n runes.
+func deletions(s string, n int) []string {
+	res := []string{s}
+	seen := map[string]struct{}{s: {}}
+	level := [][]rune{[]rune(s)}
+	for i := 0; i < n; i++ {
+		var next [][]rune
+		for _, rs := range level {
+			for j := range rs {
+				del := make([]rune, 0, len(rs)-1)
+				del = append(del, rs[:j]...)
+				del = append(del, rs[j+1:]...)
+				str := string(del)
+				if _, ok := seen[str]; ok {
+					continue
+				}
+				seen[str] = struct{}{}
+				res = append(res, str)
+				next = append(next, del)
+			}
+		}
+		level = next
+	}
+	return res
+}
@@ -0,0 +1,40 @@
+package index_test
+
+import (
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymSpell(t *testing.T) {
+	// deletions take a lot of memory, so only a part of the test data is used.
+	dict := dictionary(t)[:3000]
+	ss := index.NewSymSpell(2)
+	ss.Build(dict)
+	assert.Equal(t, len(dict), ss.Len())
+	assert.Equal(t, 2, ss.MaxDist())
+	assert.False(t, ss.Add(dict[0]))
+
+	for _, q := range queries {
+		for _, k := range []int{0, 1, 2} {
+			assert.Equal(t, bruteForce(dict, q, k), ss.Lookup(q, k), q)
+		}
+		// distance is limited by the maximum of the dictionary.
+		assert.Equal(t, bruteForce(dict, q, 2), ss.Lookup(q, 5), q)
+	}
+}
+
+func TestSymSpellShort(t *testing.T) {
+	ss := index.NewSymSpell(2)
+	ss.Build([]string{"a", "ab", "abc", "abcd", "xyz", "щука"})
+	assert.Equal(t, []index.Match{
+		{Term: "ab", EditDist: 0},
+		{Term: "a", EditDist: 1},
+		{Term: "abc", EditDist: 1},
+		{Term: "abcd", EditDist: 2},
+	}, ss.Lookup("ab", 2))
+	assert.Equal(t, []index.Match{
+		{Term: "щука", EditDist: 1},
+	}, ss.Lookup("щучка", 1))
+}
@@ -0,0 +1,168 @@
+package index
+
+import (
+	"sort"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+)
+
+// Trie is a compact prefix tree of dictionary strings. Its fuzzy search
+// walks the tree keeping one row of Levenshtein calculations per rune of
+// the path, so a shared prefix is calculated only once. Whole subtrees are
+// pruned as soon as the minimum of the row exceeds the requested edit
+// distance. Trie is not safe for concurrent inserts, but it can be queried
+// concurrently.
+type Trie struct {
+	root *trieNode
+	size int
+}
+
+// trieNode is a node of a compact trie. A chain of nodes with one child
+// each is merged into a single node, so edge keeps all runes that lead to
+// the node from its parent. Children are sorted by the first rune of their
+// edges, which is more compact than a map and gives a deterministic order
+// of traversal. Terms are not stored, they are restored from the runes of
+// the path during search.
+type trieNode struct {
+	edge     []rune
+	children []*trieNode
+	isTerm   bool
+}
+
+// NewTrie creates an empty Trie.
+func NewTrie() *Trie {
+	return &Trie{root: &trieNode{}}
+}
+
+// Len returns the number of strings in the trie.
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// Insert adds a string to the trie. It returns false if the string is
+// already in the trie.
+func (t *Trie) Insert(term string) bool {
+	node := t.root
+	rs := []rune(term)
+	for len(rs) > 0 {
+		i, ok := node.find(rs[0])
+		if !ok {
+			node.insertChild(i, &trieNode{edge: rs, isTerm: true})
+			t.size++
+			return true
+		}
+		child := node.children[i]
+		p := commonPrefix(child.edge, rs)
+		if p < len(child.edge) {
+			child = child.split(p)
+			node.children[i] = child
+		}
+		node = child
+		rs = rs[p:]
+	}
+	if node.isTerm {
+		return false
+	}
+	node.isTerm = true
+	t.size++
+	return true
+}
+
+// Build adds many strings to the trie.
+func (t *Trie) Build(terms []string) {
+	for _, v := range terms {
+		t.Insert(v)
+	}
+}
+
+// Search returns all strings of the trie within edit distance k from the
+// query. If diff is true, matches contain tagged differences between the
+// query and found strings. Matches are sorted by edit distance and then
+// alphabetically.
+func (t *Trie) Search(query string, k int, diff bool) []Match {
+	var res []Match
+	if k < 0 {
+		return res
+	}
+	rs := editdist.NewRowState(query)
+	if t.root.isTerm && rs.Distance() <= k {
+		res = append(res, Match{Term: "", EditDist: rs.Distance()})
+	}
+	res = t.root.search(rs, k, nil, res)
+
+	if diff {
+		for i := range res {
+			_, res[i].Tags1, res[i].Tags2 = editdist.ComputeDistance(
+				query, res[i].Term, true,
+			)
+		}
+	}
+	sortMatches(res)
+	return res
+}
+
+// search walks the children of the node rune by rune. The path keeps the
+// runes from the root to the node, so terms are restored from it.
+func (n *trieNode) search(
+	rs *editdist.RowState,
+	k int,
+	path []rune,
+	res []Match,
+) []Match {
+	for _, child := range n.children {
+		steps := 0
+		for _, r := range child.edge {
+			rs.Step(r)
+			steps++
+			if !rs.CanMatch(k) {
+				break
+			}
+		}
+		if steps == len(child.edge) && rs.CanMatch(k) {
+			path := append(path, child.edge...)
+			if d := rs.Distance(); child.isTerm && d <= k {
+				res = append(res, Match{Term: string(path), EditDist: d})
+			}
+			res = child.search(rs, k, path, res)
+		}
+		rs.Rollback(steps)
+	}
+	return res
+}
+
+// find returns the index of a child with the edge that starts with a rune.
+// If there is no such child, it returns the index where it should be
+// inserted.
+func (n *trieNode) find(r rune) (int, bool) {
+	i := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].edge[0] >= r
+	})
+	return i, i < len(n.children) && n.children[i].edge[0] == r
+}
+
+// insertChild inserts a child at the index, keeping children sorted.
+func (n *trieNode) insertChild(i int, child *trieNode) {
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = child
+}
+
+// split divides the edge of the node after p runes. It returns a new node
+// with the first part of the edge, the node becomes its only child.
+func (n *trieNode) split(p int) *trieNode {
+	res := &trieNode{
+		edge:     n.edge[:p:p],
+		children: []*trieNode{n},
+	}
+	n.edge = n.edge[p:]
+	return res
+}
+
+// commonPrefix returns the number of runes at the start of both slices.
+func commonPrefix(a, b []rune) int {
+	var i int
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,59 @@
+package index_test
+
+import (
+	"testing"
+
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrie(t *testing.T) {
+	dict := dictionary(t)
+	tr := index.NewTrie()
+	tr.Build(dict)
+	assert.Equal(t, len(dict), tr.Len())
+	assert.False(t, tr.Insert(dict[0]))
+
+	for _, q := range queries {
+		for _, k := range []int{0, 1, 2} {
+			assert.Equal(t, bruteForce(dict, q, k), tr.Search(q, k, false), q)
+		}
+	}
+}
+
+func TestTrieDiff(t *testing.T) {
+	tr := index.NewTrie()
+	tr.Build([]string{"", "Pomatomus", "Pomatomus saltator", "Puma"})
+	assert.Equal(t, []index.Match{
+		{Term: "Pomatomus", EditDist: 0,
+			Tags1: "Pomatomus", Tags2: "Pomatomus"},
+	}, tr.Search("Pomatomus", 0, true))
+
+	res := tr.Search("Pomatomas", 6, true)
+	assert.Equal(t, 2, len(res))
+	assert.Equal(t, "Pomatom<subst>a</subst>s", res[0].Tags1)
+	assert.Equal(t, "Pomatom<subst>u</subst>s", res[0].Tags2)
+	assert.Equal(t, "Puma", res[1].Term)
+
+	res = tr.Search("Pu", 2, false)
+	assert.Equal(t, []index.Match{
+		{Term: "", EditDist: 2},
+		{Term: "Puma", EditDist: 2},
+	}, res)
+}
+
+// TestTrieSplit checks that terms are restored when edges are split by
+// shorter terms or by terms that diverge in the middle of an edge.
+func TestTrieSplit(t *testing.T) {
+	terms := []string{"Pomatomus saltator", "Pomatomus", "Poma", "Pomacea", "P"}
+	tr := index.NewTrie()
+	tr.Build(terms)
+	assert.Equal(t, len(terms), tr.Len())
+	for _, v := range terms {
+		assert.False(t, tr.Insert(v), v)
+		assert.Equal(t, []index.Match{{Term: v, EditDist: 0}},
+			tr.Search(v, 0, false), v)
+	}
+	assert.Equal(t, bruteForce(terms, "Pomatomas", 9),
+		tr.Search("Pomatomas", 9, false))
+}
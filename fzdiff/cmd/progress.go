@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gnames/levenshtein"
+)
+
+// progressEvery is the number of compared pairs between progress reports.
+const progressEvery = 100_000
+
+// progressBar writes progress of a file processing to STDERR. The size of
+// the input is not known in pairs, so ETA is estimated from the number of
+// bytes read from the input.
+type progressBar struct {
+	size int64
+	read atomic.Int64
+}
+
+// reader wraps the input, so the progress bar knows how much of it is
+// read. The size of the input is known only for regular files.
+func (b *progressBar) reader(f io.Reader) io.Reader {
+	if file, ok := f.(*os.File); ok {
+		if stat, err := file.Stat(); err == nil && stat.Mode().IsRegular() {
+			b.size = stat.Size()
+		}
+	}
+	return &countReader{r: f, read: &b.read}
+}
+
+func (b *progressBar) report(p levenshtein.Progress) {
+	eta := "unknown"
+	if read := b.read.Load(); b.size > 0 && read > 0 {
+		left := float64(b.size-read) / float64(read)
+		eta = time.Duration(float64(p.Elapsed) * left).Round(time.Second).String()
+	}
+	fmt.Fprintf(os.Stderr,
+		"Processed %d pairs, %.0f pairs/sec, aborted: %d, ETA: %s\n",
+		p.Done, p.Rate(), p.Aborted, eta)
+}
+
+// countReader counts bytes read from the underlying reader.
+type countReader struct {
+	r    io.Reader
+	read *atomic.Int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
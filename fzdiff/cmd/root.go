@@ -22,6 +22,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -37,6 +38,10 @@ import (
 
 var opts []levenshtein.Option
 
+// bar reports progress of a file processing, it is nil if the progress
+// flag is not set.
+var bar *progressBar
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "fzdiff",
@@ -58,11 +63,31 @@ var rootCmd = &cobra.Command{
 			frmt = gnfmt.CSV
 		}
 
-		withTags, _ := cmd.Flags().GetBool("tags")
-		opts = append(opts, levenshtein.OptWithDiff(withTags))
+		var cfg levenshtein.Config
+		cfg.WithDiff, _ = cmd.Flags().GetBool("tags")
+		cfg.MaxEditDist, _ = cmd.Flags().GetInt("max_edit_distance")
+		cfg.Pattern, _ = cmd.Flags().GetBool("pattern")
+		cfg.CaseCost, _ = cmd.Flags().GetFloat64("case_cost")
+		cfg.Jobs, _ = cmd.Flags().GetInt("jobs")
+
+		utf8Mode, _ := cmd.Flags().GetString("invalid_utf8")
+		mode, err := levenshtein.NewUTF8Mode(utf8Mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg.InvalidUTF8 = mode
+
+		progress, _ := cmd.Flags().GetBool("progress")
+		if progress {
+			bar = &progressBar{}
+			cfg.ProgressEvery = progressEvery
+			cfg.ProgressFn = bar.report
+		}
 
-		maxEditDist, _ := cmd.Flags().GetInt("max_edit_distance")
-		opts = append(opts, levenshtein.OptMaxEditDist(maxEditDist))
+		if err = cfg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, cfg.Opts()...)
 
 		l := levenshtein.NewLevenshtein(opts...)
 
@@ -89,6 +114,16 @@ func init() {
 	rootCmd.Flags().BoolP("version", "V", false, "Prints version information")
 	rootCmd.Flags().BoolP("tags", "t", false, "Adds diff tags into strings.")
 	rootCmd.Flags().IntP("max_edit_distance", "m", 0, "Max threshold for edit distance.")
+	rootCmd.Flags().BoolP("pattern", "p", false,
+		"Treats the first string as a pattern with '?' and '[...]' wildcards.")
+	rootCmd.Flags().Float64P("case_cost", "c", 0,
+		"Cost of a substitution that changes only case, for example 0.25.")
+	rootCmd.Flags().StringP("invalid_utf8", "u", "replace",
+		`How to compare strings with invalid UTF-8: "replace", "error", "bytes".`)
+	rootCmd.Flags().IntP("jobs", "j", 0,
+		"Number of parallel workers, the default is the number of CPUs.")
+	rootCmd.Flags().BoolP("progress", "P", false,
+		"Writes progress of a file processing to STDERR.")
 	rootCmd.Flags().StringP("format", "f", "csv", `Format of the output: "compact", "pretty", "csv", "tsv".
   compact: compact JSON,
   pretty: pretty JSON,
@@ -161,19 +196,41 @@ func compare(l levenshtein.Levenshtein, data []string,
 }
 
 func compareFile(l levenshtein.Levenshtein, f io.Reader, frmt gnfmt.Format) {
-	batch := make([]levenshtein.Strings, 0, levenshtein.Batch)
 	if frmt == gnfmt.CSV {
 		fmt.Println(gnfmt.ToCSV(presenter.CSVHeader(), ','))
 	}
 	if frmt == gnfmt.TSV {
 		fmt.Println(gnfmt.ToCSV(presenter.CSVHeader(), '\t'))
 	}
-	r := csv.NewReader(f)
 
+	if bar != nil {
+		f = bar.reader(f)
+	}
+	chIn := make(chan levenshtein.Strings)
+	go readFile(f, chIn)
+
+	var row int
+	for v := range l.CompareStream(context.Background(), chIn) {
+		row++
+		if v.Error != "" {
+			log.Printf("Row %d: %s", row, v.Error)
+		}
+		res, err := v.Encode(frmt)
+		if err != nil {
+			log.Fatalf("cannot encode %s: %s", frmt.String(), err)
+		}
+		fmt.Println(res)
+	}
+}
+
+// readFile sends the first 2 fields of every CSV row to the channel.
+func readFile(f io.Reader, chIn chan<- levenshtein.Strings) {
+	defer close(chIn)
+	r := csv.NewReader(f)
 	for {
 		row, err := r.Read()
 		if err == io.EOF {
-			break
+			return
 		}
 		if err != nil {
 			log.Fatalf("Cannot read CSV file: %s", err)
@@ -181,33 +238,16 @@ func compareFile(l levenshtein.Levenshtein, f io.Reader, frmt gnfmt.Format) {
 		if len(row) < 2 {
 			log.Fatalf("There is less than 2 strings in a row %+v: %s", row, err)
 		}
-		if len(batch) < levenshtein.Batch {
-			batch = append(batch, levenshtein.Strings{String1: row[0], String2: row[1]})
-		} else {
-			out := l.CompareMult(batch)
-			for _, v := range out {
-				res, err := v.Encode(frmt)
-				if err != nil {
-					log.Fatalf("cannot encode %s: %s", frmt.String(), err)
-				}
-				fmt.Println(res)
-			}
-			batch = batch[:0]
-		}
-	}
-	out := l.CompareMult(batch)
-	for _, v := range out {
-		res, err := v.Encode(frmt)
-		if err != nil {
-			log.Fatalf("cannot encode %s: %s", frmt.String(), err)
-		}
-		fmt.Println(res)
+		chIn <- levenshtein.Strings{String1: row[0], String2: row[1]}
 	}
 }
 
 func compareStrings(l levenshtein.Levenshtein, data []string,
 	frmt gnfmt.Format) {
 	out := l.Compare(data[0], data[1])
+	if out.Error != "" {
+		log.Print(out.Error)
+	}
 	res, err := out.Encode(frmt)
 	if err != nil {
 		log.Fatal(err)
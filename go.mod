@@ -5,10 +5,9 @@ go 1.21
 require (
 	github.com/gnames/gnfmt v0.4.3
 	github.com/gnames/gnsys v0.2.2
-	github.com/gnames/gnuuid v0.1.1
-	github.com/google/uuid v1.3.1
 	github.com/spf13/cobra v1.7.0
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -19,5 +18,4 @@ require (
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -1,6 +1,7 @@
 package levenshtein
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gnames/levenshtein/presenter"
@@ -18,8 +19,68 @@ type Levenshtein interface {
 	// the input.
 	CompareMult(input []Strings) []presenter.Output
 
+	// CompareMultContext works like CompareMult, but stops when the context
+	// is canceled or its deadline is exceeded. In such case it returns the
+	// error of the context together with the results for the beginning of
+	// the input that was finished before the cancellation.
+	CompareMultContext(
+		ctx context.Context,
+		input []Strings,
+	) ([]presenter.Output, error)
+
+	// CompareStream calculates edit distance between pairs of strings
+	// from the input channel, and sends results to the returned channel in
+	// the same order as the input. Only a few chunks of pairs are kept in
+	// memory at a time, so inputs of any size can be processed. The output
+	// channel is closed when the input is closed and all results are sent,
+	// or when the context is canceled. The output has to be read until it
+	// is closed, or the context has to be canceled, otherwise workers stay
+	// blocked.
+	CompareStream(
+		ctx context.Context,
+		input <-chan Strings,
+	) <-chan presenter.Output
+
+	// CompareOneToMany calculates edit distance between a query and many
+	// candidate strings. The query is preprocessed only once, and the job
+	// is parallelized. The results are in the same order as candidates.
+	// Only plain edit distance, with or without OptMaxEditDist, uses the
+	// preprocessed query. With OptWithDiff, OptPattern or OptCaseCost, and
+	// for invalid UTF-8 outside of the "replace" mode, every candidate is
+	// compared by Compare, so results are the same, but there is no speedup.
+	CompareOneToMany(query string, candidates []string) []presenter.Output
+
+	// TopK returns up to k candidates that are the closest to the query,
+	// sorted by edit distance. Ties are broken by candidate strings and
+	// then by their order in the input. Candidates beyond the maximum
+	// edit distance, if it is set, are not included. Ranking always uses
+	// the integer edit distance, OptCaseCost does not change the order,
+	// and its Cost is only reported in the outputs.
+	TopK(query string, candidates []string, k int) []presenter.Output
+
+	// SelfJoin finds all pairs of terms that are within edit distance k
+	// from each other, without comparing every term with every other one.
+	// Candidates are found by length and q-gram filters, and verified in
+	// parallel. Every pair (i, j), where i < j are positions of terms, is
+	// sent once, ordered by i and then by j. Identical terms are pairs
+	// with zero distance. Pairs that cannot be compared (for example,
+	// because of invalid UTF-8) are sent with an error. Options are the
+	// same as for Compare, except for patterns that are not supported.
+	// The output is closed when all pairs are sent, or when the context
+	// is canceled. The output has to be read until it is closed, or the
+	// context has to be canceled.
+	SelfJoin(
+		ctx context.Context,
+		terms []string,
+		k int,
+	) <-chan presenter.Output
+
 	// Option returns back options applied to the Levenshtein implementation.
 	Opts() []Option
+
+	// Config returns all settings of the Levenshtein implementation.
+	// NewLevenshtein(l.Config().Opts()...) creates an equivalent object.
+	Config() Config
 }
 
 func Example() {
@@ -0,0 +1,176 @@
+package levenshtein
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gnames/levenshtein/ent/index"
+	"github.com/gnames/levenshtein/presenter"
+)
+
+// joinQ is the length of q-grams used to find candidates for self-joins.
+const joinQ = 2
+
+// SelfJoin is an implementation of Levenshtein interface.
+func (l levenshtein) SelfJoin(
+	ctx context.Context,
+	terms []string,
+	k int,
+) <-chan presenter.Output {
+	chOut := make(chan presenter.Output)
+	if k < 0 {
+		close(chOut)
+		return chOut
+	}
+
+	// candidates are verified with the maximum edit distance k, patterns
+	// are not supported by q-gram filters.
+	j := NewLevenshtein(append(l.Opts(), OptMaxEditDist(k), OptPattern(false))...)
+	workers := max(j.jobs, 1)
+	chWork := make(chan *streamChunk)
+	// chOrder keeps chunks in the order of terms, and limits the number of
+	// chunks in progress.
+	chOrder := make(chan *streamChunk, workers)
+	t := j.newTracker(0)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	go func() {
+		defer close(chOrder)
+		defer close(chWork)
+
+		idx := j.newJoinIndex(terms)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				j.joinWorker(ctx, terms, k, idx, chWork, t)
+			}()
+		}
+
+		chunk := max(j.chunkSize, 1)
+		for start := 0; start < len(terms); start += chunk {
+			c := &streamChunk{
+				start: start,
+				end:   min(start+chunk, len(terms)),
+				done:  make(chan struct{}),
+			}
+			for _, ch := range []chan<- *streamChunk{chOrder, chWork} {
+				select {
+				case ch <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	go emitChunks(ctx, chOrder, chOut, &wg, t)
+	return chOut
+}
+
+// joinIndex keeps q-gram indexes of distinct terms, and positions of
+// every distinct term in the input, in the order of the index IDs.
+type joinIndex struct {
+	qg        *index.QGram
+	positions [][]int
+
+	// bytes keeps terms converted by bytesToRunes. It is used only in
+	// UTF8Bytes mode when some terms are not valid UTF-8, because pairs
+	// with such terms are compared byte by byte, and their edit distance
+	// differs from the one of runes.
+	bytes *index.QGram
+	// invalid marks terms that are not valid UTF-8.
+	invalid []bool
+}
+
+// newJoinIndex creates indexes of distinct terms.
+func (l levenshtein) newJoinIndex(terms []string) *joinIndex {
+	res := &joinIndex{qg: index.NewQGram(joinQ)}
+	var distinct []string
+	var hasInvalid bool
+	ids := make(map[string]int)
+	for i, v := range terms {
+		if id, ok := ids[v]; ok {
+			res.positions[id] = append(res.positions[id], i)
+			continue
+		}
+		ids[v] = len(res.positions)
+		res.positions = append(res.positions, []int{i})
+		res.qg.Add(v)
+		distinct = append(distinct, v)
+		res.invalid = append(res.invalid, !utf8.ValidString(v))
+		hasInvalid = hasInvalid || !utf8.ValidString(v)
+	}
+
+	if l.invalidUTF8 == UTF8Bytes && hasInvalid {
+		res.bytes = index.NewQGram(joinQ)
+		for _, v := range distinct {
+			res.bytes.Add(bytesToRunes(v))
+		}
+	}
+	return res
+}
+
+// candidates returns sorted IDs of distinct terms that might be within
+// edit distance k from the term. A valid term is compared by runes with
+// valid terms, and by bytes with invalid ones, an invalid term is
+// compared by bytes with all terms.
+func (idx *joinIndex) candidates(term string, k int) []int {
+	if idx.bytes == nil {
+		return idx.qg.Candidates(term, k)
+	}
+
+	byBytes := idx.bytes.Candidates(bytesToRunes(term), k)
+	if !utf8.ValidString(term) {
+		return byBytes
+	}
+	res := idx.qg.Candidates(term, k)
+	for _, id := range byBytes {
+		if idx.invalid[id] {
+			res = append(res, id)
+		}
+	}
+	sort.Ints(res)
+	return slices.Compact(res)
+}
+
+// joinWorker finds pairs for chunks of terms. For every term it verifies
+// candidates that follow the term in the input, so every pair is found
+// once. The context is checked before every term, so a canceled join
+// does not wait for the rest of a chunk.
+func (l levenshtein) joinWorker(
+	ctx context.Context,
+	terms []string,
+	k int,
+	idx *joinIndex,
+	chWork <-chan *streamChunk,
+	t *tracker,
+) {
+	w := l.worker()
+	var js []int
+	for c := range chWork {
+		for i := c.start; i < c.end && ctx.Err() == nil; i++ {
+			js = js[:0]
+			for _, id := range idx.candidates(terms[i], k) {
+				for _, j := range idx.positions[id] {
+					if j > i {
+						js = append(js, j)
+					}
+				}
+			}
+			sort.Ints(js)
+
+			for _, j := range js {
+				out := w.Compare(terms[i], terms[j])
+				t.add(out)
+				if out.Error != "" || (!out.Aborted && out.EditDist <= k) {
+					c.out = append(c.out, out)
+				}
+			}
+		}
+		close(c.done)
+	}
+}
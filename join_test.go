@@ -0,0 +1,153 @@
+package levenshtein_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gnames/levenshtein"
+	"github.com/gnames/levenshtein/presenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfJoin(t *testing.T) {
+	pairs := fuzzyPairs(t, 1)[:300]
+	terms := make([]string, 0, 2*len(pairs)+2)
+	for _, v := range pairs {
+		terms = append(terms, v.String1, v.String2)
+	}
+	// duplicates are pairs with zero distance.
+	terms = append(terms, terms[5], terms[0])
+
+	optsData := [][]levenshtein.Option{
+		nil,
+		{levenshtein.OptWithDiff(true), levenshtein.OptJobs(3)},
+		{levenshtein.OptChunkSize(1), levenshtein.OptMaxEditDist(10)},
+	}
+	for _, opts := range optsData {
+		fd := levenshtein.NewLevenshtein(opts...)
+		for _, k := range []int{0, 1, 2} {
+			msg := fmt.Sprintf("k %d, options %d", k, len(opts))
+			var exp []presenter.Output
+			fdk := levenshtein.NewLevenshtein(
+				append(fd.Opts(), levenshtein.OptMaxEditDist(k))...,
+			)
+			for i := range terms {
+				for j := i + 1; j < len(terms); j++ {
+					out := fdk.Compare(terms[i], terms[j])
+					if !out.Aborted && out.EditDist <= k {
+						exp = append(exp, out)
+					}
+				}
+			}
+
+			var res []presenter.Output
+			for v := range fd.SelfJoin(context.Background(), terms, k) {
+				res = append(res, v)
+			}
+			assert.Greater(t, len(exp), 0, msg)
+			assert.Equal(t, exp, res, msg)
+		}
+	}
+}
+
+// TestSelfJoinBytes checks that pairs with invalid UTF-8 are found by
+// their edit distance in bytes in UTF8Bytes mode.
+func TestSelfJoinBytes(t *testing.T) {
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptInvalidUTF8(levenshtein.UTF8Bytes),
+	)
+	var res []presenter.Output
+	for v := range fd.SelfJoin(context.Background(), []string{"\xc3\xa9", "\xc3\xff"}, 1) {
+		res = append(res, v)
+	}
+	if assert.Equal(t, 1, len(res)) {
+		assert.Equal(t, 1, res[0].EditDist)
+	}
+
+	terms := []string{
+		"Pumé", "Puma", "Pum\xc3", "Pum\xc3\xa9\xff", "Pumé\xff", "Puma\xff",
+		"\xff", "é", "e", "", "Pomatomus", "Pomatomus\xfe", "Pomatomas",
+	}
+	for _, k := range []int{0, 1, 2, 3} {
+		var exp []presenter.Output
+		fdk := levenshtein.NewLevenshtein(
+			append(fd.Opts(), levenshtein.OptMaxEditDist(k))...,
+		)
+		for i := range terms {
+			for j := i + 1; j < len(terms); j++ {
+				out := fdk.Compare(terms[i], terms[j])
+				if !out.Aborted && out.EditDist <= k {
+					exp = append(exp, out)
+				}
+			}
+		}
+
+		res = res[:0]
+		for v := range fd.SelfJoin(context.Background(), terms, k) {
+			res = append(res, v)
+		}
+		assert.Equal(t, exp, res, k)
+	}
+}
+
+func TestSelfJoinEmpty(t *testing.T) {
+	fd := levenshtein.NewLevenshtein()
+	var count int
+	for range fd.SelfJoin(context.Background(), []string{"Puma", "Boston"}, -1) {
+		count++
+	}
+	for range fd.SelfJoin(context.Background(), nil, 2) {
+		count++
+	}
+	for range fd.SelfJoin(context.Background(), []string{"Puma", "Boston"}, 1) {
+		count++
+	}
+	assert.Equal(t, 0, count)
+}
+
+// TestSelfJoinCancel cancels a join in the middle of large chunks, and
+// checks that the output is closed promptly, and that workers do not
+// report progress after that.
+func TestSelfJoinCancel(t *testing.T) {
+	pairs := fuzzyPairs(t, 1)
+	terms := make([]string, len(pairs))
+	for i, v := range pairs {
+		terms[i] = v.String1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	canceled := make(chan struct{})
+	var once sync.Once
+	var closed atomic.Bool
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptJobs(4),
+		levenshtein.OptChunkSize(len(terms)),
+		levenshtein.OptProgress(100, func(levenshtein.Progress) {
+			assert.False(t, closed.Load(), "progress after close")
+			once.Do(func() {
+				cancel()
+				close(canceled)
+			})
+		}),
+	)
+	chOut := fd.SelfJoin(ctx, terms, 3)
+	<-canceled
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-chOut:
+			if !ok {
+				closed.Store(true)
+				return
+			}
+		case <-deadline:
+			t.Fatal("output is not closed after cancellation")
+		}
+	}
+}
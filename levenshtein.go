@@ -1,22 +1,27 @@
 package levenshtein
 
 import (
+	"context"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 
-	"github.com/gnames/gnuuid"
 	"github.com/gnames/levenshtein/ent/editdist"
 	"github.com/gnames/levenshtein/presenter"
-	u "github.com/google/uuid"
 )
 
-// Batch can be used to break a very large input into chunks.
+// Batch can be used to break a very large input into chunks. It is not
+// used by the library itself, the number of parallel workers and the size
+// of their chunks are set by OptJobs and OptChunkSize.
+//
+// Deprecated: use CompareStream to process large inputs.
 var Batch = 10_000
 
-// job is a hardcoded number of jobs used to parallelize CompareMult
-// jobs. 16 seem to be a reasonable number, that would not slow things
-// down even on a 1 processor machine, and more than 16 go-routines
-// seem to show diminishing returns.
-var jobs = 16
+// defaultChunkSize is a number of pairs a worker takes at once. Comparison
+// of two short names takes less time than passing them to a worker one by
+// one, so workers take pairs in chunks.
+const defaultChunkSize = 64
 
 // Option is an 'interface' for creating Options for Levensthsein,
 // that modify its behavior.
@@ -40,19 +45,103 @@ func OptMaxEditDist(i int) Option {
 	}
 }
 
+// OptPattern if set to true, the first string of every comparison is
+// treated as a pattern, where `?` matches any rune, and `[...]` matches
+// any rune from a set. Look at editdist.Pattern for the full syntax.
+func OptPattern(b bool) Option {
+	return func(l *levenshtein) {
+		l.pattern = b
+	}
+}
+
+// OptCaseCost sets a cost of substitutions of runes that differ only by
+// case (for example 0.25). Such substitutions are marked by "case" tags,
+// and the weighted distance is returned as Cost of the output. The cost
+// should be between 0 and 1, zero value disables the option. Negative,
+// infinite or NaN values are rejected and disable the option as well.
+// The option is ignored for patterns. EditDist stays the plain
+// Levenshtein distance, while tags and edit counts follow the alignment
+// with the lowest Cost, so they can show more edits than EditDist.
+func OptCaseCost(f float64) Option {
+	return func(l *levenshtein) {
+		if checkCaseCost(f) != nil {
+			f = 0
+		}
+		l.caseCost = f
+	}
+}
+
+// OptJobs sets the number of parallel workers for CompareMult and
+// CompareOneToMany. Zero or negative value sets it to GOMAXPROCS, which is
+// also the default.
+func OptJobs(i int) Option {
+	return func(l *levenshtein) {
+		if i <= 0 {
+			i = runtime.GOMAXPROCS(0)
+		}
+		l.jobs = i
+	}
+}
+
+// OptChunkSize sets the number of pairs a worker takes at once. Zero or
+// negative value sets it to the default of 64 pairs.
+func OptChunkSize(i int) Option {
+	return func(l *levenshtein) {
+		if i <= 0 {
+			i = defaultChunkSize
+		}
+		l.chunkSize = i
+	}
+}
+
 // levenshtein is an implementation of Levenshtein interface.
 type levenshtein struct {
 	withDiff    bool
 	maxEditDist int
+	pattern     bool
+	caseCost    float64
+	jobs        int
+	chunkSize   int
+
+	progressEvery int
+	progressFn    func(Progress)
+
+	invalidUTF8 UTF8Mode
+
+	cache *Cache
+	// cacheOpts is a part of cache keys that describes settings.
+	cacheOpts string
+
+	// buf is scratch memory of a worker. If it is nil, a buffer is taken
+	// from bufPool.
+	buf *editdist.Buffer
+}
+
+// bufPool keeps buffers for Compare calls outside of workers.
+var bufPool = sync.Pool{
+	New: func() any { return new(editdist.Buffer) },
+}
+
+// worker returns a copy of levenshtein with its own scratch memory, that
+// is reused by all comparisons of a worker.
+func (l levenshtein) worker() levenshtein {
+	l.buf = new(editdist.Buffer)
+	return l
 }
 
 // NewLevenshtein returns an object that implements Levenshtein
 // interface.
 func NewLevenshtein(opts ...Option) levenshtein {
-	l := levenshtein{}
+	l := levenshtein{
+		jobs:      runtime.GOMAXPROCS(0),
+		chunkSize: defaultChunkSize,
+	}
 	for _, opt := range opts {
 		opt(&l)
 	}
+	if l.cache != nil {
+		l.cacheOpts = l.cacheKeyOpts()
+	}
 	return l
 }
 
@@ -63,82 +152,277 @@ type Strings struct {
 
 // Compare is an implementation of Levenshtein interface.
 func (l levenshtein) Compare(str1, str2 string) presenter.Output {
+	if l.cache == nil {
+		return l.compareUTF8(str1, str2)
+	}
+	k := cacheKey{str1: str1, str2: str2, opts: l.cacheOpts}
+	if res, ok := l.cache.get(k); ok {
+		return res
+	}
+	res := l.compareUTF8(str1, str2)
+	l.cache.add(k, res)
+	return res
+}
+
+// compareUTF8 checks if strings are valid UTF-8 before comparing them.
+func (l levenshtein) compareUTF8(str1, str2 string) presenter.Output {
+	if l.invalidUTF8 != UTF8Replace &&
+		!(utf8.ValidString(str1) && utf8.ValidString(str2)) {
+		return l.compareInvalid(str1, str2)
+	}
+	return l.compare(str1, str2)
+}
+
+// compare calculates edit distance between two strings, where invalid
+// UTF-8 bytes are treated as U+FFFD characters.
+func (l levenshtein) compare(str1, str2 string) presenter.Output {
+	if l.pattern {
+		return l.comparePattern(str1, str2)
+	}
+
+	buf := l.buf
+	if buf == nil {
+		buf = bufPool.Get().(*editdist.Buffer)
+		defer bufPool.Put(buf)
+	}
+
 	var ed int
 	var t1, t2 string
 	var aborted bool
 	if l.maxEditDist > 0 {
-		ed, aborted = editdist.ComputeDistanceMax(str1, str2, l.maxEditDist)
+		ed, aborted = buf.ComputeDistanceMax(str1, str2, l.maxEditDist)
 	}
 
-	if !aborted {
-		ed, t1, t2 = editdist.ComputeDistance(str1, str2, l.withDiff)
+	var cost *float64
+	var st editdist.Stats
+	switch {
+	case aborted:
+	case l.caseCost > 0 && l.withDiff:
+		var c float64
+		c, ed, t1, t2, st = editdist.ComputeDistanceCaseStats(
+			str1, str2, l.caseCost,
+		)
+		cost = &c
+	case l.caseCost > 0:
+		var c float64
+		c, ed, _, _ = editdist.ComputeDistanceCase(
+			str1, str2, l.caseCost, false,
+		)
+		cost = &c
+	case l.withDiff:
+		ed, t1, t2, st = buf.ComputeDistanceStats(str1, str2)
+	default:
+		ed, _, _ = buf.ComputeDistance(str1, str2, false)
+	}
+	if aborted || !l.withDiff {
+		st = editdist.ComputeAffixes(str1, str2)
 	}
 
-	return presenter.Output{
+	res := presenter.Output{
 		String1:  str1,
 		String2:  str2,
 		Tags1:    t1,
 		Tags2:    t2,
 		EditDist: ed,
+		Cost:     cost,
 		Aborted:  aborted,
 	}
+	setStats(&res, st)
+	return res
+}
+
+func (l levenshtein) comparePattern(pattern, str string) presenter.Output {
+	var ed int
+	var t1, t2 string
+	var aborted bool
+	if l.maxEditDist > 0 {
+		ed, aborted = editdist.ComputeDistancePatternMax(
+			pattern, str, l.maxEditDist,
+		)
+	}
+
+	var st editdist.Stats
+	switch {
+	case aborted:
+	case l.withDiff:
+		ed, t1, t2, st = editdist.ComputeDistancePatternStats(pattern, str)
+	default:
+		ed, _, _ = editdist.ComputeDistancePattern(pattern, str, false)
+	}
+	if aborted || !l.withDiff {
+		st = editdist.ComputeAffixesPattern(pattern, str)
+	}
+
+	res := presenter.Output{
+		String1:  pattern,
+		String2:  str,
+		Tags1:    t1,
+		Tags2:    t2,
+		EditDist: ed,
+		Aborted:  aborted,
+	}
+	setStats(&res, st)
+	return res
+}
+
+// setStats copies statistics of edit operations to the output.
+func setStats(o *presenter.Output, st editdist.Stats) {
+	o.Substitutions = st.Substitutions
+	o.Insertions = st.Insertions
+	o.Deletions = st.Deletions
+	o.CommonPrefix = st.CommonPrefix
+	o.CommonSuffix = st.CommonSuffix
+	o.FirstDiff = st.FirstDiff
 }
 
 // Opts is an implementation of Levenshtein interface.
 func (l levenshtein) Opts() []Option {
-	return []Option{OptWithDiff(l.withDiff)}
+	return l.Config().Opts()
+}
+
+// Config is an implementation of Levenshtein interface.
+func (l levenshtein) Config() Config {
+	return Config{
+		WithDiff:      l.withDiff,
+		MaxEditDist:   l.maxEditDist,
+		Pattern:       l.pattern,
+		CaseCost:      l.caseCost,
+		Jobs:          l.jobs,
+		ChunkSize:     l.chunkSize,
+		ProgressEvery: l.progressEvery,
+		ProgressFn:    l.progressFn,
+		InvalidUTF8:   l.invalidUTF8,
+		CacheSize:     l.cache.Size(),
+		Cache:         l.cache,
+	}
 }
 
 // CompareMult is an implementation of Levenshtein interface.
 func (l levenshtein) CompareMult(inp []Strings) []presenter.Output {
-	outMap := make(map[u.UUID]presenter.Output)
-	chIn := make(chan Strings)
-	chOut := make(chan presenter.Output)
-	var wgWorker sync.WaitGroup
-	wgWorker.Add(jobs)
-	var wgFin sync.WaitGroup
-	wgFin.Add(1)
-
-	go func() {
-		for _, v := range inp {
-			chIn <- v
-		}
-		close(chIn)
-	}()
-	for i := 0; i < jobs; i++ {
-		go l.compareWorker(chIn, chOut, &wgWorker)
-	}
-	go func() {
-		defer wgFin.Done()
-		for out := range chOut {
-			outMap[getUUID(out.String1, out.String2)] = out
+	res := make([]presenter.Output, len(inp))
+	t := l.newTracker(len(inp))
+	_ = l.parallel(context.Background(), len(inp), func() func(int) {
+		w := l.worker()
+		return func(i int) {
+			res[i] = w.Compare(inp[i].String1, inp[i].String2)
+			t.add(res[i])
 		}
-	}()
-
-	wgWorker.Wait()
-	close(chOut)
-
-	wgFin.Wait()
+	})
+	t.finish()
+	return res
+}
 
+// CompareMultContext is an implementation of Levenshtein interface.
+func (l levenshtein) CompareMultContext(
+	ctx context.Context,
+	inp []Strings,
+) ([]presenter.Output, error) {
 	res := make([]presenter.Output, len(inp))
-	for i, v := range inp {
-		res[i] = outMap[getUUID(v.String1, v.String2)]
+	done := make([]bool, len(inp))
+	t := l.newTracker(len(inp))
+	err := l.parallel(ctx, len(inp), func() func(int) {
+		w := l.worker()
+		return func(i int) {
+			res[i] = w.Compare(inp[i].String1, inp[i].String2)
+			done[i] = true
+			t.add(res[i])
+		}
+	})
+	t.finish()
+	if err == nil {
+		return res, nil
+	}
+
+	// only the beginning of the input that is finished without gaps is
+	// returned, so results stay aligned with the input.
+	var i int
+	for i < len(done) && done[i] {
+		i++
 	}
+	return res[:i], err
+}
+
+// CompareOneToMany is an implementation of Levenshtein interface.
+func (l levenshtein) CompareOneToMany(
+	query string,
+	candidates []string,
+) []presenter.Output {
+	res := make([]presenter.Output, len(candidates))
+	q := editdist.NewQuery(query)
+	_ = l.parallel(context.Background(), len(candidates), func() func(int) {
+		return func(i int) {
+			res[i] = l.compareQuery(q, candidates[i])
+		}
+	})
 	return res
 }
 
-func (l levenshtein) compareWorker(
-	chIn <-chan Strings,
-	chOut chan<- presenter.Output,
-	wg *sync.WaitGroup,
-) {
-	defer wg.Done()
-	l = NewLevenshtein(l.Opts()...)
-	for v := range chIn {
-		chOut <- l.Compare(v.String1, v.String2)
+// parallel runs a job for every index from 0 to n-1. Every worker gets
+// its job function from newJob, and then pulls chunks of indices until
+// all of them are taken. Every index is processed only once, so jobs can
+// write to their own elements of a preallocated slice. Workers stop
+// when the context is canceled, in such case the error of the context
+// is returned, and some indices stay unprocessed. All workers are finished
+// when parallel returns.
+func (l levenshtein) parallel(
+	ctx context.Context,
+	n int,
+	newJob func() func(int),
+) error {
+	chunk := max(l.chunkSize, 1)
+	workers := min(max(l.jobs, 1), (n+chunk-1)/chunk)
+	var next, finished atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			job := newJob()
+			for {
+				end := int(next.Add(int64(chunk)))
+				start := end - chunk
+				if start >= n {
+					return
+				}
+				for i := start; i < min(end, n); i++ {
+					select {
+					case <-ctx.Done():
+						return
+					default:
+					}
+					job(i)
+					finished.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if int(finished.Load()) < n {
+		return ctx.Err()
 	}
+	return nil
 }
 
-func getUUID(str1, str2 string) u.UUID {
-	return gnuuid.New(str1 + "\v|\v" + str2)
+// compareQuery compares a preprocessed query with a string. Only plain
+// edit distance benefits from preprocessing, all other modes fall back
+// to Compare.
+func (l levenshtein) compareQuery(
+	q *editdist.Query,
+	str string,
+) presenter.Output {
+	if l.pattern || l.withDiff || l.caseCost > 0 ||
+		(l.invalidUTF8 != UTF8Replace &&
+			!(utf8.ValidString(q.String()) && utf8.ValidString(str))) {
+		return l.Compare(q.String(), str)
+	}
+
+	res := presenter.Output{String1: q.String(), String2: str}
+	if l.maxEditDist > 0 {
+		res.EditDist, res.Aborted = q.DistanceMax(str, l.maxEditDist)
+	} else {
+		res.EditDist = q.Distance(str)
+	}
+	setStats(&res, editdist.ComputeAffixes(q.String(), str))
+	return res
 }
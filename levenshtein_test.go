@@ -1,10 +1,19 @@
 package levenshtein_test
 
 import (
+	"context"
+	"encoding/csv"
 	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gnames/gnfmt"
 	"github.com/gnames/levenshtein"
+	"github.com/gnames/levenshtein/ent/editdist"
 	"github.com/gnames/levenshtein/presenter"
 	"github.com/stretchr/testify/assert"
 )
@@ -102,6 +111,153 @@ func TestNoDiff(t *testing.T) {
 	}
 }
 
+func TestPattern(t *testing.T) {
+	testData := []struct {
+		str1     string
+		str2     string
+		editDist int
+		aborted  bool
+		tags1    string
+		tags2    string
+	}{
+		{"Amblyseius hib?sci", "Amblyseius hibisci", 0, false,
+			"Amblyseius hib?sci", "Amblyseius hibisci"},
+		{"[ck]ristata", "kristata", 0, false, "[ck]ristata", "kristata"},
+		{"[ck]ristat?", "xristatus", 2, false,
+			"<subst>[ck]</subst>ristat?<del>s</del>",
+			"<subst>x</subst>ristatu<ins>s</ins>"},
+		{"[ck]ristata", "Boston", 2, true, "", ""},
+	}
+
+	var fd levenshtein.Levenshtein
+	opts := []levenshtein.Option{
+		levenshtein.OptWithDiff(true),
+		levenshtein.OptMaxEditDist(2),
+		levenshtein.OptPattern(true),
+	}
+	fd = levenshtein.NewLevenshtein(opts...)
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		out := fd.Compare(v.str1, v.str2)
+		assert.Equal(t, v.editDist, out.EditDist, msg)
+		assert.Equal(t, v.aborted, out.Aborted, msg)
+		assert.Equal(t, v.tags1, out.Tags1, msg)
+		assert.Equal(t, v.tags2, out.Tags2, msg)
+	}
+}
+
+func TestCaseCost(t *testing.T) {
+	testData := []struct {
+		str1     string
+		str2     string
+		editDist int
+		cost     float64
+		tags1    string
+		tags2    string
+	}{
+		{"aablyseius", "Aablyseius", 1, 0.25,
+			"<case>a</case>ablyseius", "<case>A</case>ablyseius"},
+		{"aablyseius", "Amblyseius", 2, 1.25,
+			"<case>a</case><subst>a</subst>blyseius",
+			"<case>A</case><subst>m</subst>blyseius"},
+		{"Aablyseius", "Aablyseius", 0, 0, "Aablyseius", "Aablyseius"},
+		// tags follow the cheapest alignment, it has more edits than EditDist.
+		{"ABx", "xab", 3, 2.5,
+			"<del>x</del><case>AB</case><ins>x</ins>",
+			"<ins>x</ins><case>ab</case><del>x</del>"},
+	}
+
+	var fd levenshtein.Levenshtein
+	opts := []levenshtein.Option{
+		levenshtein.OptWithDiff(true),
+		levenshtein.OptCaseCost(0.25),
+	}
+	fd = levenshtein.NewLevenshtein(opts...)
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		out := fd.Compare(v.str1, v.str2)
+		assert.Equal(t, v.editDist, out.EditDist, msg)
+		if assert.NotNil(t, out.Cost, msg) {
+			assert.InDelta(t, v.cost, *out.Cost, 1e-9, msg)
+		}
+		assert.Equal(t, v.tags1, out.Tags1, msg)
+		assert.Equal(t, v.tags2, out.Tags2, msg)
+	}
+
+	// invalid costs disable the option.
+	for _, f := range []float64{0, -0.25, math.NaN(), math.Inf(1)} {
+		fd = levenshtein.NewLevenshtein(levenshtein.OptCaseCost(f))
+		out := fd.Compare("aablyseius", "Aablyseius")
+		assert.Nil(t, out.Cost, f)
+		assert.Equal(t, 1, out.EditDist, f)
+	}
+}
+
+// TestEncodeCSV checks that new columns follow the original ones and that
+// Cost is empty when the cost of case changes is not set.
+func TestEncodeCSV(t *testing.T) {
+	header := presenter.CSVHeader()
+	assert.Equal(t, []string{"String1", "String2", "Tags1", "Tags2",
+		"EditDistance", "Aborted"}, header[:6])
+	assert.Equal(t, "Error", header[len(header)-1])
+
+	testData := []struct {
+		caseCost float64
+		cost     string
+	}{
+		{0, ""},
+		{0.25, "0.25"},
+	}
+	for _, v := range testData {
+		fd := levenshtein.NewLevenshtein(levenshtein.OptCaseCost(v.caseCost))
+		res, err := fd.Compare("Boston", "boston").Encode(gnfmt.CSV)
+		assert.Nil(t, err)
+		row, err := csv.NewReader(strings.NewReader(res)).Read()
+		assert.Nil(t, err)
+		assert.Equal(t, len(header), len(row))
+		assert.Equal(t, "1", row[4])
+		assert.Equal(t, "false", row[5])
+		assert.Equal(t, v.cost, row[6])
+	}
+}
+
+func TestStats(t *testing.T) {
+	fd := levenshtein.NewLevenshtein(levenshtein.OptWithDiff(true))
+	out := fd.Compare("Poma tomus", "Pomatomos")
+	assert.Equal(t, "Poma<ins> </ins>tom<subst>u</subst>s", out.Tags1)
+	assert.Equal(t, "Poma<del> </del>tom<subst>o</subst>s", out.Tags2)
+	assert.Equal(t, 1, out.Substitutions)
+	assert.Equal(t, 1, out.Insertions)
+	assert.Equal(t, 0, out.Deletions)
+	assert.Equal(t, 4, out.CommonPrefix)
+	assert.Equal(t, 1, out.CommonSuffix)
+	assert.Equal(t, 5, out.FirstDiff)
+
+	// counts of edit operations need diffs, the rest is always there.
+	testData := []struct {
+		msg  string
+		opts []levenshtein.Option
+	}{
+		{"plain", nil},
+		{"max", []levenshtein.Option{levenshtein.OptMaxEditDist(1)}},
+		{"case", []levenshtein.Option{levenshtein.OptCaseCost(0.25)}},
+	}
+	for _, v := range testData {
+		fd = levenshtein.NewLevenshtein(v.opts...)
+		out = fd.Compare("Poma tomus", "Pomatomos")
+		assert.Equal(t, 0, out.Substitutions, v.msg)
+		assert.Equal(t, 0, out.Insertions, v.msg)
+		assert.Equal(t, 4, out.CommonPrefix, v.msg)
+		assert.Equal(t, 1, out.CommonSuffix, v.msg)
+		assert.Equal(t, 5, out.FirstDiff, v.msg)
+	}
+
+	fd = levenshtein.NewLevenshtein(levenshtein.OptPattern(true))
+	out = fd.Compare("[ck]ristat?", "kristatus")
+	assert.Equal(t, 8, out.CommonPrefix)
+	assert.Equal(t, 9, out.FirstDiff)
+}
+
 func TestMult(t *testing.T) {
 	testData := []struct {
 		str1     string
@@ -128,6 +284,162 @@ func TestMult(t *testing.T) {
 	}
 }
 
+func TestMultDuplicates(t *testing.T) {
+	str := []levenshtein.Strings{
+		{String1: "Puma", String2: "Poma"},
+		{String1: "Puma", String2: "Poma"},
+		{String1: "a\v|\vb", String2: "c"},
+		{String1: "a", String2: "b\v|\vc"},
+		{String1: "Puma", String2: "Poma"},
+		{String1: "", String2: ""},
+	}
+	fd := levenshtein.NewLevenshtein(levenshtein.OptWithDiff(true))
+	out := fd.CompareMult(str)
+	assert.Equal(t, len(str), len(out))
+	for i, v := range out {
+		assert.Equal(t, fd.Compare(str[i].String1, str[i].String2), v)
+	}
+}
+
+func TestMultLarge(t *testing.T) {
+	str := fuzzyPairs(t, 10)
+	fd := levenshtein.NewLevenshtein()
+	out := fd.CompareMult(str)
+	assert.Equal(t, len(str), len(out))
+	for i, v := range out {
+		assert.Equal(t, str[i].String1, v.String1)
+		assert.Equal(t, str[i].String2, v.String2)
+		dist, _, _ := editdist.ComputeDistance(v.String1, v.String2, false)
+		assert.Equal(t, dist, v.EditDist)
+	}
+}
+
+func TestMultContext(t *testing.T) {
+	str := fuzzyPairs(t, 1)[:1000]
+	fd := levenshtein.NewLevenshtein(levenshtein.OptWithDiff(true))
+	out, err := fd.CompareMultContext(context.Background(), str)
+	assert.Nil(t, err)
+	assert.Equal(t, fd.CompareMult(str), out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out, err = fd.CompareMultContext(ctx, str)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, len(out))
+}
+
+// TestMultContextCancel cancels a run in the middle, and checks that
+// CompareMultContext returns promptly, and that workers do nothing after
+// the return. Races between workers and the caller are caught by -race.
+func TestMultContextCancel(t *testing.T) {
+	str := fuzzyPairs(t, 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var returned atomic.Bool
+	var canceled time.Time
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptWithDiff(true),
+		levenshtein.OptJobs(4),
+		levenshtein.OptChunkSize(1),
+		levenshtein.OptProgress(100, func(p levenshtein.Progress) {
+			assert.False(t, returned.Load(), "progress after return")
+			if canceled.IsZero() && p.Done >= 1000 {
+				canceled = time.Now()
+				cancel()
+			}
+		}),
+	)
+	out, err := fd.CompareMultContext(ctx, str)
+	returned.Store(true)
+	elapsed := time.Since(canceled)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, elapsed, time.Second)
+	assert.GreaterOrEqual(t, len(out), 1000-4)
+	assert.Less(t, len(out), len(str))
+	for i, v := range out {
+		assert.Equal(t, fd.Compare(str[i].String1, str[i].String2), v)
+	}
+
+	// deadline is handled the same way as cancellation.
+	fd = levenshtein.NewLevenshtein(levenshtein.OptJobs(4))
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = fd.CompareMultContext(ctx, str)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMultJobs(t *testing.T) {
+	str := []levenshtein.Strings{
+		{String1: "Puma", String2: "Poma"},
+		{String1: "Puma concolor", String2: "Pomacancolor"},
+		{String1: "Pomatomus", String2: "Pomatomas"},
+		{String1: "Aablyseius", String2: "Amblyseius"},
+		{String1: "", String2: "abc"},
+	}
+	exp := levenshtein.NewLevenshtein().CompareMult(str)
+
+	testData := []struct {
+		jobs, chunk int
+	}{
+		{0, 0}, {1, 1}, {2, 3}, {16, 1}, {3, 100}, {-1, -1},
+	}
+	for _, v := range testData {
+		msg := fmt.Sprintf("jobs %d, chunk %d", v.jobs, v.chunk)
+		fd := levenshtein.NewLevenshtein(
+			levenshtein.OptJobs(v.jobs),
+			levenshtein.OptChunkSize(v.chunk),
+		)
+		assert.Equal(t, exp, fd.CompareMult(str), msg)
+		assert.Equal(t, 0, len(fd.CompareMult(nil)), msg)
+	}
+}
+
+func TestOneToMany(t *testing.T) {
+	f, err := os.Open("testdata/fuzzy.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.Nil(t, err)
+
+	query := "Aablyseius hibisci"
+	cands := make([]string, len(rows))
+	for i, row := range rows {
+		cands[i] = row[1]
+	}
+
+	optsData := [][]levenshtein.Option{
+		nil,
+		{levenshtein.OptMaxEditDist(2)},
+		{levenshtein.OptWithDiff(true), levenshtein.OptMaxEditDist(3)},
+	}
+	for _, opts := range optsData {
+		fd := levenshtein.NewLevenshtein(opts...)
+		out := fd.CompareOneToMany(query, cands)
+		assert.Equal(t, len(cands), len(out))
+		for i, v := range out {
+			assert.Equal(t, fd.Compare(query, cands[i]), v)
+		}
+	}
+}
+
+func TestCompareAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations of pooled buffers are random with race detector")
+	}
+	a, b := "Pomatomus solatror", "Pomatomus saltator"
+	d := levenshtein.NewLevenshtein()
+	d.Compare(a, b)
+	allocs := testing.AllocsPerRun(100, func() { d.Compare(a, b) })
+	assert.Equal(t, 0.0, allocs)
+
+	dDiff := levenshtein.NewLevenshtein(levenshtein.OptWithDiff(true))
+	dDiff.Compare(a, b)
+	allocs = testing.AllocsPerRun(100, func() { dDiff.Compare(a, b) })
+	assert.LessOrEqual(t, allocs, 2.0)
+}
+
 // BenchmarkCompare checks the speed of fuzzy matching. Run it with:
 // `go test -bench=. -benchmem -count=10 -run=XXX > bench.txt && benchstat bench.txt`
 func BenchmarkCompare(b *testing.B) {
@@ -148,3 +460,22 @@ func BenchmarkCompare(b *testing.B) {
 		_ = fmt.Sprintf("%d\n", out.EditDist)
 	})
 }
+
+// fuzzyPairs returns pairs of strings from testdata/fuzzy.csv, repeated
+// n times. Every copy is shifted, so the same pairs are mixed up.
+func fuzzyPairs(t testing.TB, n int) []levenshtein.Strings {
+	f, err := os.Open("testdata/fuzzy.csv")
+	assert.Nil(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.Nil(t, err)
+
+	res := make([]levenshtein.Strings, 0, n*len(rows))
+	for i := 0; i < n; i++ {
+		for j := range rows {
+			row := rows[(i+j)%len(rows)]
+			res = append(res, levenshtein.Strings{String1: row[0], String2: row[1]})
+		}
+	}
+	return res
+}
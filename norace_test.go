@@ -0,0 +1,5 @@
+//go:build !race
+
+package levenshtein_test
+
+const raceEnabled = false
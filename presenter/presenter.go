@@ -23,9 +23,38 @@ type Output struct {
 	// set it might not show the actual edit distance between two
 	// strings.
 	EditDist int `json:"editDistance"`
+	// Cost is the weighted edit distance, where substitutions that change
+	// only case of a character have a reduced cost. It is nil unless such
+	// cost is set, so a real zero cost can be told apart from a missing one.
+	Cost *float64 `json:"cost,omitempty"`
+	// Substitutions is the number of substituted characters. This and the
+	// following two counts are calculated only when diff tags are
+	// requested, otherwise they are 0. If Cost is set, the tags and the
+	// counts describe the cheapest alignment, which can have more edits
+	// than EditDist (for example "ABx" and "xab").
+	Substitutions int `json:"substitutions,omitempty"`
+	// Insertions is the number of characters that exist only in the
+	// first string. They are marked by "ins" tags in Tags1.
+	Insertions int `json:"insertions,omitempty"`
+	// Deletions is the number of characters that exist only in the second
+	// string. They are marked by "del" tags in Tags1.
+	Deletions int `json:"deletions,omitempty"`
+	// CommonPrefix is the number of identical characters at the start of
+	// both strings. This and the following two fields are calculated for
+	// every comparison, with or without diff tags.
+	CommonPrefix int `json:"commonPrefix,omitempty"`
+	// CommonSuffix is the number of identical characters at the end of
+	// both strings.
+	CommonSuffix int `json:"commonSuffix,omitempty"`
+	// FirstDiff is the position of the first differing character
+	// counting from 1. It is 0 if strings are identical.
+	FirstDiff int `json:"firstDiff,omitempty"`
 	// Aborted is true if Maximum Edit Distance is provided, and
 	// it was exceeded during calculations.
 	Aborted bool `json:"aborted,omitempty"`
+	// Error describes why strings could not be compared, for example
+	// because of invalid UTF-8. EditDist is -1 in such case.
+	Error string `json:"error,omitempty"`
 }
 
 // Encode method produces representation of Output for consumption
@@ -51,7 +80,8 @@ func (o Output) Encode(f gnfmt.Format) (string, error) {
 func CSVHeader() []string {
 	return []string{
 		"String1", "String2", "Tags1", "Tags2",
-		"EditDistance", "Aborted",
+		"EditDistance", "Aborted", "Cost", "Substitutions", "Insertions",
+		"Deletions", "CommonPrefix", "CommonSuffix", "FirstDiff", "Error",
 	}
 }
 
@@ -64,8 +94,15 @@ func (o Output) encodeTSV() (string, error) {
 }
 
 func (o Output) encodeSV(sep rune) (string, error) {
+	var cost string
+	if o.Cost != nil {
+		cost = strconv.FormatFloat(*o.Cost, 'f', -1, 64)
+	}
 	row := []string{o.String1, o.String2, o.Tags1, o.Tags2,
-		strconv.Itoa(o.EditDist), strconv.FormatBool(o.Aborted),
+		strconv.Itoa(o.EditDist), strconv.FormatBool(o.Aborted), cost,
+		strconv.Itoa(o.Substitutions), strconv.Itoa(o.Insertions),
+		strconv.Itoa(o.Deletions), strconv.Itoa(o.CommonPrefix),
+		strconv.Itoa(o.CommonSuffix), strconv.Itoa(o.FirstDiff), o.Error,
 	}
 	return gnfmt.ToCSV(row, sep), nil
 }
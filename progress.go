@@ -0,0 +1,145 @@
+package levenshtein
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gnames/levenshtein/presenter"
+)
+
+// Progress describes how far a batch run went.
+type Progress struct {
+	// Total is the number of pairs in the input, it is zero when the size
+	// of the input is not known, as for CompareStream.
+	Total int
+
+	// Done is the number of compared pairs.
+	Done int
+
+	// Aborted is the number of pairs where calculations were aborted
+	// because of the maximum edit distance.
+	Aborted int
+
+	// Elapsed is the time since the start of the run.
+	Elapsed time.Duration
+}
+
+// Rate returns the number of compared pairs per second.
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Done) / p.Elapsed.Seconds()
+}
+
+// ETA returns estimated time until the end of the run. It is zero if
+// the total number of pairs is not known.
+func (p Progress) ETA() time.Duration {
+	if p.Total == 0 || p.Done == 0 {
+		return 0
+	}
+	left := p.Total - p.Done
+	return time.Duration(float64(p.Elapsed) * float64(left) / float64(p.Done))
+}
+
+// OptProgress sets a function that is called every n compared pairs
+// during CompareMult, CompareMultContext and CompareStream, and once more
+// at the end of a run. With many workers a report can include a few more
+// pairs than a multiple of n, and a report overtaken by a later one is
+// skipped. Calls are never concurrent, and Done never goes back, so the
+// function does not need to be thread-safe, but it should be fast,
+// because the worker that reached a multiple of n waits for it. Zero or
+// negative n, or nil function disable progress reports.
+func OptProgress(n int, fn func(Progress)) Option {
+	return func(l *levenshtein) {
+		if n <= 0 || fn == nil {
+			l.progressEvery, l.progressFn = 0, nil
+			return
+		}
+		l.progressEvery, l.progressFn = n, fn
+	}
+}
+
+// tracker counts compared pairs of one run and reports progress.
+type tracker struct {
+	every int
+	fn    func(Progress)
+	total int
+	start time.Time
+
+	// done and aborted are counted without a lock, so workers do not wait
+	// for each other on every pair.
+	done, aborted atomic.Int64
+
+	// mu serializes calls of the progress function, so reports come in
+	// order, and Done never goes back.
+	mu sync.Mutex
+	// reported is the value of done at the last report.
+	reported int64
+}
+
+// newTracker returns a tracker for a run, or nil if progress reports
+// are disabled.
+func (l levenshtein) newTracker(total int) *tracker {
+	if l.progressFn == nil {
+		return nil
+	}
+	return &tracker{
+		every: l.progressEvery,
+		fn:    l.progressFn,
+		total: total,
+		start: time.Now(),
+	}
+}
+
+// add counts a compared pair, and reports progress every n pairs.
+func (t *tracker) add(o presenter.Output) {
+	if t == nil {
+		return
+	}
+	// aborted is counted first, so a report that sees a pair as done
+	// sees it as aborted too.
+	if o.Aborted {
+		t.aborted.Add(1)
+	}
+	n := t.done.Add(1)
+	if n%int64(t.every) != 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.report()
+}
+
+// finish reports progress at the end of a run, unless it is already
+// reported for the last compared pair. It has to be called after all
+// workers of the run are finished.
+func (t *tracker) finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.report()
+}
+
+// report calls the progress function, the mutex has to be locked. Workers
+// keep counting while a report waits for the lock, so it takes the latest
+// counts, and is skipped if they are already reported by a later report.
+// Aborted can include pairs that are not counted as done yet, so it is
+// limited by Done.
+func (t *tracker) report() {
+	done := t.done.Load()
+	aborted := min(t.aborted.Load(), done)
+	if done <= t.reported {
+		return
+	}
+	t.reported = done
+	t.fn(Progress{
+		Total:   t.total,
+		Done:    int(done),
+		Aborted: int(aborted),
+		Elapsed: time.Since(t.start),
+	})
+}
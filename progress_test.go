@@ -0,0 +1,120 @@
+package levenshtein_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gnames/levenshtein"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	str := fuzzyPairs(t, 1)[:1050]
+	var reports []levenshtein.Progress
+	// one worker reports exactly every 100 pairs, many workers might count
+	// more pairs while a report waits for its turn.
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptMaxEditDist(1),
+		levenshtein.OptJobs(1),
+		levenshtein.OptProgress(100, func(p levenshtein.Progress) {
+			reports = append(reports, p)
+		}),
+	)
+	out := fd.CompareMult(str)
+	var aborted int
+	for _, v := range out {
+		if v.Aborted {
+			aborted++
+		}
+	}
+
+	assert.Equal(t, 11, len(reports))
+	for i, v := range reports {
+		assert.Equal(t, len(str), v.Total)
+		if i < 10 {
+			assert.Equal(t, (i+1)*100, v.Done)
+		}
+	}
+	last := reports[len(reports)-1]
+	assert.Equal(t, len(str), last.Done)
+	assert.Equal(t, aborted, last.Aborted)
+	assert.Equal(t, time.Duration(0), last.ETA())
+
+	// streaming input has no total.
+	reports = reports[:0]
+	chIn := make(chan levenshtein.Strings)
+	go func() {
+		for _, v := range str {
+			chIn <- v
+		}
+		close(chIn)
+	}()
+	for range fd.CompareStream(context.Background(), chIn) {
+	}
+	assert.Equal(t, 11, len(reports))
+	assert.Equal(t, 0, reports[0].Total)
+	assert.Equal(t, len(str), reports[10].Done)
+}
+
+// TestProgressOrder checks that reports from many workers come in order,
+// and that a canceled stream does not report after its output is closed.
+func TestProgressOrder(t *testing.T) {
+	str := fuzzyPairs(t, 2)
+	var reports []levenshtein.Progress
+	var closed atomic.Bool
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptMaxEditDist(1),
+		levenshtein.OptJobs(8),
+		levenshtein.OptChunkSize(1),
+		levenshtein.OptProgress(7, func(p levenshtein.Progress) {
+			assert.False(t, closed.Load(), "progress after close")
+			reports = append(reports, p)
+		}),
+	)
+	out := fd.CompareMult(str)
+	var aborted int
+	for _, v := range out {
+		if v.Aborted {
+			aborted++
+		}
+	}
+	for i, v := range reports {
+		assert.LessOrEqual(t, v.Aborted, v.Done)
+		if i > 0 {
+			assert.Greater(t, v.Done, reports[i-1].Done)
+			assert.GreaterOrEqual(t, v.Aborted, reports[i-1].Aborted)
+		}
+	}
+	last := reports[len(reports)-1]
+	assert.Equal(t, len(str), last.Done)
+	assert.Equal(t, aborted, last.Aborted)
+
+	reports = reports[:0]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	chIn := make(chan levenshtein.Strings, len(str))
+	for _, v := range str {
+		chIn <- v
+	}
+	chOut := fd.CompareStream(ctx, chIn)
+	for i := 0; i < 1000; i++ {
+		<-chOut
+	}
+	cancel()
+	for range chOut {
+	}
+	closed.Store(true)
+	last = reports[len(reports)-1]
+	assert.GreaterOrEqual(t, last.Done, 1000)
+	assert.Less(t, last.Done, len(str))
+}
+
+func TestProgressETA(t *testing.T) {
+	p := levenshtein.Progress{Total: 300, Done: 100, Elapsed: 2 * time.Second}
+	assert.Equal(t, 50.0, p.Rate())
+	assert.Equal(t, 4*time.Second, p.ETA())
+	assert.Equal(t, time.Duration(0), levenshtein.Progress{}.ETA())
+	assert.Equal(t, 0.0, levenshtein.Progress{}.Rate())
+}
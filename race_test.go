@@ -0,0 +1,7 @@
+//go:build race
+
+package levenshtein_test
+
+// raceEnabled is true when tests run with the race detector, that makes
+// sync.Pool drop items at random.
+const raceEnabled = true
@@ -0,0 +1,110 @@
+package levenshtein
+
+import (
+	"container/heap"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/gnames/levenshtein/ent/editdist"
+	"github.com/gnames/levenshtein/presenter"
+)
+
+// ranked is a candidate that made it into top-K results.
+type ranked struct {
+	idx  int
+	str  string
+	dist int
+}
+
+// less sorts ranked candidates by edit distance, ties are broken by
+// the candidate string, and then by its position in the input.
+func (r ranked) less(o ranked) bool {
+	if r.dist != o.dist {
+		return r.dist < o.dist
+	}
+	if r.str != o.str {
+		return r.str < o.str
+	}
+	return r.idx < o.idx
+}
+
+// rankHeap is a max-heap that keeps the worst of the top-K candidates
+// on top.
+type rankHeap []ranked
+
+func (h rankHeap) Len() int           { return len(h) }
+func (h rankHeap) Less(i, j int) bool { return h[j].less(h[i]) }
+func (h rankHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *rankHeap) Push(x any)        { *h = append(*h, x.(ranked)) }
+func (h *rankHeap) Pop() any {
+	old := *h
+	n := len(old)
+	res := old[n-1]
+	*h = old[:n-1]
+	return res
+}
+
+// TopK is an implementation of Levenshtein interface.
+func (l levenshtein) TopK(
+	query string,
+	candidates []string,
+	k int,
+) []presenter.Output {
+	if k <= 0 {
+		return nil
+	}
+
+	q := editdist.NewQuery(query)
+	validQuery := utf8.ValidString(query)
+	dist := func(str string, max int) (int, bool) {
+		if l.invalidUTF8 != UTF8Replace && !(validQuery && utf8.ValidString(str)) {
+			// strings that cannot be compared are not ranked.
+			if l.invalidUTF8 == UTF8Error {
+				return max, true
+			}
+			query, str := bytesToRunes(query), bytesToRunes(str)
+			if l.pattern {
+				return editdist.ComputeDistancePatternMax(query, str, max)
+			}
+			return editdist.ComputeDistanceMax(query, str, max)
+		}
+		if l.pattern {
+			return editdist.ComputeDistancePatternMax(query, str, max)
+		}
+		return q.DistanceMax(str, max)
+	}
+
+	h := make(rankHeap, 0, k)
+	for i, v := range candidates {
+		// the cutoff gets tighter as the heap fills with better candidates.
+		cutoff := l.maxEditDist
+		if len(h) == k {
+			// k exact matches cannot be beaten by later candidates, and zero
+			// cutoff would mean no limit at all.
+			if h[0].dist == 0 {
+				break
+			}
+			cutoff = h[0].dist
+		}
+		d, aborted := dist(v, cutoff)
+		if aborted {
+			continue
+		}
+		r := ranked{idx: i, str: v, dist: d}
+		if len(h) < k {
+			heap.Push(&h, r)
+			continue
+		}
+		if r.less(h[0]) {
+			h[0] = r
+			heap.Fix(&h, 0)
+		}
+	}
+
+	sort.Slice(h, func(i, j int) bool { return h[i].less(h[j]) })
+	res := make([]presenter.Output, len(h))
+	for i, v := range h {
+		res[i] = l.Compare(query, v.str)
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+package levenshtein_test
+
+import (
+	"testing"
+
+	"github.com/gnames/levenshtein"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopK(t *testing.T) {
+	cands := []string{
+		"Pomatomus saltator",
+		"Pomatomus",
+		"Pomatomus saltatrix",
+		"Pomatomus saltator",
+		"Pomatomus saltatot",
+		"Pomatomus saltatos",
+		"Boston",
+	}
+
+	fd := levenshtein.NewLevenshtein()
+	res := fd.TopK("Pomatomus saltator", cands, 4)
+	var strs []string
+	var dists []int
+	for _, v := range res {
+		strs = append(strs, v.String2)
+		dists = append(dists, v.EditDist)
+	}
+	assert.Equal(t, []string{
+		"Pomatomus saltator",
+		"Pomatomus saltator",
+		"Pomatomus saltatos",
+		"Pomatomus saltatot",
+	}, strs)
+	assert.Equal(t, []int{0, 0, 1, 1}, dists)
+
+	res = fd.TopK("Pomatomus saltator", cands, 100)
+	assert.Equal(t, len(cands), len(res))
+	assert.Equal(t, "Boston", res[len(res)-1].String2)
+
+	assert.Nil(t, fd.TopK("Pomatomus saltator", cands, 0))
+
+	fd = levenshtein.NewLevenshtein(
+		levenshtein.OptMaxEditDist(2),
+		levenshtein.OptWithDiff(true),
+	)
+	res = fd.TopK("Pomatomus saltator", cands, 100)
+	assert.Equal(t, 4, len(res))
+	assert.Equal(t, "Pomatomus saltato<subst>r</subst>", res[2].Tags1)
+
+	fd = levenshtein.NewLevenshtein(levenshtein.OptPattern(true))
+	res = fd.TopK("Pomatomus saltat??", cands, 2)
+	assert.Equal(t, "Pomatomus saltator", res[0].String2)
+	assert.Equal(t, 0, res[1].EditDist)
+}
+
+// TestTopKExact checks that more than k exact matches are handled, and
+// the first k of them in the input are returned.
+func TestTopKExact(t *testing.T) {
+	cands := []string{
+		"Pomatomus saltatrix",
+		"Pomatomus saltator",
+		"Pomatomus saltator",
+		"Pomatomus saltator",
+		"Pomatomus saltator",
+		"Boston",
+	}
+	fd := levenshtein.NewLevenshtein(levenshtein.OptMaxEditDist(3))
+	res := fd.TopK("Pomatomus saltator", cands, 2)
+	assert.Equal(t, 2, len(res))
+	for _, v := range res {
+		assert.Equal(t, "Pomatomus saltator", v.String2)
+		assert.Equal(t, 0, v.EditDist)
+		assert.False(t, v.Aborted)
+	}
+
+	// case cost does not change ranking, it is only reported.
+	fd = levenshtein.NewLevenshtein(levenshtein.OptCaseCost(0.25))
+	res = fd.TopK("Boston", []string{"Bostan", "BOSTON"}, 1)
+	assert.Equal(t, "Bostan", res[0].String2)
+	assert.InDelta(t, 1, *res[0].Cost, 1e-9)
+}
@@ -0,0 +1,143 @@
+package levenshtein
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gnames/levenshtein/presenter"
+)
+
+// streamChunk is a part of a streamed input together with its results.
+// The done channel is closed when all results of the chunk are ready.
+type streamChunk struct {
+	inp []Strings
+	// start and end are positions of terms of a self-join chunk.
+	start, end int
+	out        []presenter.Output
+	done       chan struct{}
+}
+
+// CompareStream is an implementation of Levenshtein interface.
+func (l levenshtein) CompareStream(
+	ctx context.Context,
+	input <-chan Strings,
+) <-chan presenter.Output {
+	chunk := max(l.chunkSize, 1)
+	workers := max(l.jobs, 1)
+
+	chOut := make(chan presenter.Output)
+	chWork := make(chan *streamChunk)
+	// chOrder keeps chunks in the order of the input. Its capacity limits
+	// the number of chunks in progress, and therefore the memory needed
+	// to restore the order of results.
+	chOrder := make(chan *streamChunk, workers)
+
+	t := l.newTracker(0)
+
+	go l.readStream(ctx, input, chunk, chOrder, chWork)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			w := l.worker()
+			for c := range chWork {
+				c.out = make([]presenter.Output, len(c.inp))
+				for i, v := range c.inp {
+					c.out[i] = w.Compare(v.String1, v.String2)
+					t.add(c.out[i])
+				}
+				close(c.done)
+			}
+		}()
+	}
+
+	go emitChunks(ctx, chOrder, chOut, &wg, t)
+	return chOut
+}
+
+// emitChunks waits for results of every chunk in the order of chOrder,
+// and sends them to chOut. It stops when chOrder is closed, or when the
+// context is canceled. Then it waits for workers, reports the final
+// progress, and closes chOut, so nothing runs after chOut is closed.
+func emitChunks(
+	ctx context.Context,
+	chOrder <-chan *streamChunk,
+	chOut chan<- presenter.Output,
+	wg *sync.WaitGroup,
+	t *tracker,
+) {
+	defer close(chOut)
+	defer t.finish()
+	defer wg.Wait()
+	for c := range chOrder {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return
+		}
+		for _, v := range c.out {
+			select {
+			case chOut <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// readStream breaks the input into chunks and sends every chunk first to
+// the chOrder channel, and then to workers. It waits for the first pair of
+// a chunk, and then takes only pairs that are already available, so a slow
+// input does not hold finished pairs back.
+func (l levenshtein) readStream(
+	ctx context.Context,
+	input <-chan Strings,
+	chunk int,
+	chOrder, chWork chan<- *streamChunk,
+) {
+	defer close(chOrder)
+	defer close(chWork)
+
+	for {
+		var v Strings
+		var ok bool
+		select {
+		case v, ok = <-input:
+		case <-ctx.Done():
+			return
+		}
+		if !ok {
+			return
+		}
+
+		c := &streamChunk{
+			inp:  make([]Strings, 1, chunk),
+			done: make(chan struct{}),
+		}
+		c.inp[0] = v
+	fill:
+		for len(c.inp) < chunk {
+			select {
+			case v, ok = <-input:
+				if !ok {
+					break fill
+				}
+				c.inp = append(c.inp, v)
+			default:
+				break fill
+			}
+		}
+
+		for _, ch := range []chan<- *streamChunk{chOrder, chWork} {
+			select {
+			case ch <- c:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if !ok {
+			return
+		}
+	}
+}
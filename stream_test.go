@@ -0,0 +1,80 @@
+package levenshtein_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gnames/levenshtein"
+	"github.com/gnames/levenshtein/presenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	str := fuzzyPairs(t, 2)
+	optsData := [][]levenshtein.Option{
+		nil,
+		{levenshtein.OptWithDiff(true), levenshtein.OptJobs(3)},
+		{levenshtein.OptJobs(1), levenshtein.OptChunkSize(1)},
+	}
+	for _, opts := range optsData {
+		fd := levenshtein.NewLevenshtein(opts...)
+		exp := fd.CompareMult(str)
+
+		chIn := make(chan levenshtein.Strings)
+		go func() {
+			for _, v := range str {
+				chIn <- v
+			}
+			close(chIn)
+		}()
+		var res []presenter.Output
+		for v := range fd.CompareStream(context.Background(), chIn) {
+			res = append(res, v)
+		}
+		assert.Equal(t, exp, res)
+	}
+}
+
+func TestStreamEmpty(t *testing.T) {
+	fd := levenshtein.NewLevenshtein()
+	chIn := make(chan levenshtein.Strings)
+	close(chIn)
+	var count int
+	for range fd.CompareStream(context.Background(), chIn) {
+		count++
+	}
+	assert.Equal(t, 0, count)
+}
+
+func TestStreamCancel(t *testing.T) {
+	str := fuzzyPairs(t, 1)
+
+	fd := levenshtein.NewLevenshtein(levenshtein.OptJobs(4))
+	ctx, cancel := context.WithCancel(context.Background())
+	// the input is never closed.
+	chIn := make(chan levenshtein.Strings, len(str))
+	for _, v := range str {
+		chIn <- v
+	}
+
+	chOut := fd.CompareStream(ctx, chIn)
+	for i := 0; i < 100; i++ {
+		v := <-chOut
+		assert.Equal(t, str[i].String2, v.String2)
+	}
+	cancel()
+
+	// the output is closed promptly, even though the input is not.
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-chOut:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("output is not closed after cancellation")
+		}
+	}
+}
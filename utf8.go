@@ -0,0 +1,161 @@
+package levenshtein
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gnames/levenshtein/presenter"
+)
+
+// UTF8Mode determines how strings with invalid UTF-8 are compared.
+type UTF8Mode int
+
+const (
+	// UTF8Replace converts every invalid byte to U+FFFD replacement
+	// character. It is the default mode. Different corrupted strings might
+	// look equal in this mode.
+	UTF8Replace UTF8Mode = iota
+
+	// UTF8Error does not compare strings with invalid UTF-8. Such outputs
+	// have an error message and the edit distance of -1.
+	UTF8Error
+
+	// UTF8Bytes compares strings byte by byte, if one of them is not
+	// a valid UTF-8. Tags then keep the original bytes, and the edit
+	// distance counts bytes instead of characters. The cost of case
+	// changes applies only to ASCII letters in this mode.
+	UTF8Bytes
+)
+
+var utf8Modes = []string{"replace", "error", "bytes"}
+
+// String returns the name of the mode.
+func (m UTF8Mode) String() string {
+	if m < 0 || int(m) >= len(utf8Modes) {
+		return fmt.Sprintf("UTF8Mode(%d)", int(m))
+	}
+	return utf8Modes[m]
+}
+
+// NewUTF8Mode returns a mode by its name ("replace", "error" or "bytes").
+// Empty name means the default mode.
+func NewUTF8Mode(s string) (UTF8Mode, error) {
+	if s == "" {
+		return UTF8Replace, nil
+	}
+	for i, v := range utf8Modes {
+		if v == s {
+			return UTF8Mode(i), nil
+		}
+	}
+	return UTF8Replace, fmt.Errorf("unknown UTF-8 mode '%s', use one of %s",
+		s, strings.Join(utf8Modes, ", "))
+}
+
+// MarshalText implements encoding.TextMarshaler, so modes are saved by
+// their names in JSON and YAML.
+func (m UTF8Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *UTF8Mode) UnmarshalText(text []byte) error {
+	res, err := NewUTF8Mode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
+
+// OptInvalidUTF8 sets how to compare strings that are not valid UTF-8.
+// By default invalid bytes are replaced with U+FFFD character.
+func OptInvalidUTF8(m UTF8Mode) Option {
+	return func(l *levenshtein) {
+		l.invalidUTF8 = m
+	}
+}
+
+// compareInvalid compares strings where at least one of them is not
+// a valid UTF-8.
+func (l levenshtein) compareInvalid(str1, str2 string) presenter.Output {
+	if l.invalidUTF8 == UTF8Error {
+		return presenter.Output{
+			String1:  str1,
+			String2:  str2,
+			EditDist: -1,
+			Error:    invalidUTF8Error(str1, str2),
+		}
+	}
+
+	res := l.compare(bytesToRunes(str1), bytesToRunes(str2))
+	res.String1, res.String2 = str1, str2
+	res.Tags1, res.Tags2 = runesToBytes(res.Tags1), runesToBytes(res.Tags2)
+	return res
+}
+
+// invalidUTF8Error describes where invalid UTF-8 is found.
+func invalidUTF8Error(str1, str2 string) string {
+	var res []string
+	for i, s := range []string{str1, str2} {
+		if pos := invalidPos(s); pos >= 0 {
+			res = append(res,
+				fmt.Sprintf("invalid UTF-8 in String%d at byte %d", i+1, pos))
+		}
+	}
+	return strings.Join(res, "; ")
+}
+
+// invalidPos returns the position of the first invalid byte, or -1 if
+// the string is a valid UTF-8.
+func invalidPos(s string) int {
+	for i, r := range s {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// byteRunes is the start of the Private Use Area of Unicode. Bytes
+// outside of ASCII are converted to runes starting from it.
+const byteRunes = 0xE000
+
+// bytesToRunes converts every byte of a string to a rune, so strings are
+// compared byte by byte. ASCII bytes keep their values, other bytes are
+// converted to runes of the Private Use Area. Such runes have no case,
+// so case changes are found only for ASCII letters, and not for random
+// bytes that look like Latin-1 letters.
+func bytesToRunes(s string) string {
+	var res strings.Builder
+	res.Grow(3 * len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] < utf8.RuneSelf {
+			res.WriteByte(s[i])
+			continue
+		}
+		res.WriteRune(byteRunes + rune(s[i]))
+	}
+	return res.String()
+}
+
+// runesToBytes restores the original bytes of a string converted by
+// bytesToRunes. Tags are ASCII, so they stay intact.
+func runesToBytes(s string) string {
+	var res strings.Builder
+	res.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf:
+			res.WriteByte(byte(r))
+		case r >= byteRunes+utf8.RuneSelf && r <= byteRunes+0xFF:
+			res.WriteByte(byte(r - byteRunes))
+		default:
+			res.WriteRune(r)
+		}
+	}
+	return res.String()
+}
@@ -0,0 +1,133 @@
+package levenshtein_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gnames/levenshtein"
+	"github.com/gnames/levenshtein/presenter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidUTF8(t *testing.T) {
+	testData := []struct {
+		mode         levenshtein.UTF8Mode
+		str1, str2   string
+		editDist     int
+		tags1, tags2 string
+		err          string
+	}{
+		// different corrupted strings look equal after replacement.
+		{levenshtein.UTF8Replace, "Puma\xff", "Puma\xfe", 0,
+			"Puma�", "Puma�", ""},
+		{levenshtein.UTF8Bytes, "Puma\xff", "Puma\xfe", 1,
+			"Puma<subst>\xff</subst>", "Puma<subst>\xfe</subst>", ""},
+		// valid multi-byte characters count by bytes.
+		{levenshtein.UTF8Bytes, "Pumé\xff", "Pume\xff", 2,
+			"Pum<ins>\xc3</ins><subst>\xa9</subst>\xff",
+			"Pum<del>\xc3</del><subst>e</subst>\xff", ""},
+		{levenshtein.UTF8Bytes, "Pumé", "Pume", 1,
+			"Pum<subst>é</subst>", "Pum<subst>e</subst>", ""},
+		{levenshtein.UTF8Error, "Puma\xff", "Puma\xfe", -1, "", "",
+			"invalid UTF-8 in String1 at byte 4; " +
+				"invalid UTF-8 in String2 at byte 4"},
+		{levenshtein.UTF8Error, "Pumé", "P\xffma", -1, "", "",
+			"invalid UTF-8 in String2 at byte 1"},
+		{levenshtein.UTF8Error, "Pumé", "Puma", 1,
+			"Pum<subst>é</subst>", "Pum<subst>a</subst>", ""},
+	}
+
+	for _, v := range testData {
+		msg := fmt.Sprintf("%s: '%s' vs '%s'", v.mode, v.str1, v.str2)
+		fd := levenshtein.NewLevenshtein(
+			levenshtein.OptWithDiff(true),
+			levenshtein.OptInvalidUTF8(v.mode),
+		)
+		res := fd.Compare(v.str1, v.str2)
+		assert.Equal(t, v.str1, res.String1, msg)
+		assert.Equal(t, v.str2, res.String2, msg)
+		assert.Equal(t, v.editDist, res.EditDist, msg)
+		assert.Equal(t, v.tags1, res.Tags1, msg)
+		assert.Equal(t, v.tags2, res.Tags2, msg)
+		assert.Equal(t, v.err, res.Error, msg)
+	}
+}
+
+// TestInvalidUTF8Case checks that bytes compared in UTF8Bytes mode are
+// not folded as Latin-1 letters, while ASCII letters keep their case cost.
+func TestInvalidUTF8Case(t *testing.T) {
+	testData := []struct {
+		str1, str2   string
+		editDist     int
+		cost         float64
+		tags1, tags2 string
+	}{
+		{"a\xc3\xff", "a\xe3\xff", 1, 1,
+			"a<subst>\xc3</subst>\xff", "a<subst>\xe3</subst>\xff"},
+		{"Puma\xff", "puma\xff", 1, 0.25,
+			"<case>P</case>uma\xff", "<case>p</case>uma\xff"},
+	}
+
+	fd := levenshtein.NewLevenshtein(
+		levenshtein.OptWithDiff(true),
+		levenshtein.OptCaseCost(0.25),
+		levenshtein.OptInvalidUTF8(levenshtein.UTF8Bytes),
+	)
+	for _, v := range testData {
+		msg := fmt.Sprintf("'%s' vs '%s'", v.str1, v.str2)
+		res := fd.Compare(v.str1, v.str2)
+		assert.Equal(t, v.editDist, res.EditDist, msg)
+		if assert.NotNil(t, res.Cost, msg) {
+			assert.InDelta(t, v.cost, *res.Cost, 1e-9, msg)
+		}
+		assert.Equal(t, v.tags1, res.Tags1, msg)
+		assert.Equal(t, v.tags2, res.Tags2, msg)
+	}
+}
+
+func TestInvalidUTF8Mult(t *testing.T) {
+	str := []levenshtein.Strings{
+		{String1: "Puma", String2: "Poma"},
+		{String1: "Puma\xff", String2: "Puma\xfe"},
+	}
+	for _, mode := range []levenshtein.UTF8Mode{
+		levenshtein.UTF8Replace, levenshtein.UTF8Error, levenshtein.UTF8Bytes,
+	} {
+		fd := levenshtein.NewLevenshtein(levenshtein.OptInvalidUTF8(mode))
+		exp := []presenter.Output{
+			fd.Compare(str[0].String1, str[0].String2),
+			fd.Compare(str[1].String1, str[1].String2),
+		}
+		assert.Equal(t, exp, fd.CompareMult(str), mode.String())
+		assert.Equal(t, exp[1:],
+			fd.CompareOneToMany(str[1].String1, []string{str[1].String2}),
+			mode.String())
+	}
+
+	fd := levenshtein.NewLevenshtein(levenshtein.OptInvalidUTF8(levenshtein.UTF8Error))
+	res := fd.TopK("Puma", []string{"Pum\xff", "Poma"}, 2)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, "Poma", res[0].String2)
+}
+
+func TestUTF8Mode(t *testing.T) {
+	for _, v := range []string{"replace", "error", "bytes"} {
+		m, err := levenshtein.NewUTF8Mode(v)
+		assert.Nil(t, err)
+		assert.Equal(t, v, m.String())
+	}
+	m, err := levenshtein.NewUTF8Mode("")
+	assert.Nil(t, err)
+	assert.Equal(t, levenshtein.UTF8Replace, m)
+	_, err = levenshtein.NewUTF8Mode("ignore")
+	assert.NotNil(t, err)
+
+	cfg, err := levenshtein.NewConfigYAML([]byte("invalidUTF8: bytes\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, levenshtein.UTF8Bytes, cfg.InvalidUTF8)
+	cfg, err = levenshtein.NewConfigJSON([]byte(`{"invalidUTF8": "error"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, levenshtein.UTF8Error, cfg.InvalidUTF8)
+	_, err = levenshtein.NewConfigJSON([]byte(`{"invalidUTF8": "ignore"}`))
+	assert.NotNil(t, err)
+}